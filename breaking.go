@@ -7,18 +7,17 @@ package breaking
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"go/ast"
-	"go/importer"
+	"go/build"
 	"go/parser"
 	"go/printer"
+	"go/scanner"
 	"go/token"
 	"go/types"
-	"io"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/sprt/breaking/internal/load"
 	"github.com/sprt/breaking/internal/typecmp"
 )
 
@@ -61,19 +60,40 @@ func (o *Object) String() string {
 	return buf.String()
 }
 
-// An ObjectDiff represents a breaking change in the representation of two objects
+// An ObjectDiff represents a change in the representation of two objects
 // that share the same name across two packages.
+//
+// An object changed in several ways is reported as several ObjectDiffs.
 type ObjectDiff struct {
-	a, b *Object
+	a, b      *Object
+	pkg       string
+	change    typecmp.Change
+	severity  Severity
+	platforms []string
 }
 
-// Name returns the name of the objects.
+// Name returns the name of the objects, or the empty string if the change
+// applies to the package as a whole.
 func (d *ObjectDiff) Name() string {
-	return d.a.obj.Name()
+	switch {
+	case d.a.obj != nil:
+		return d.a.obj.Name()
+	case d.b.obj != nil:
+		return d.b.obj.Name()
+	}
+	return ""
+}
+
+// Package returns the import path of the package containing the objects.
+func (d *ObjectDiff) Package() string {
+	return d.pkg
 }
 
-// Old returns the object before the change.
+// Old returns the object before the change, or nil if it was added.
 func (d *ObjectDiff) Old() *Object {
+	if d.a.obj == nil {
+		return nil
+	}
 	return d.a
 }
 
@@ -85,48 +105,217 @@ func (d *ObjectDiff) New() *Object {
 	return d.b
 }
 
-// ComparePackages returns the breaking changes introduced by package b
-// relative to package a.
+// Kind returns the rule that reported the change.
+func (d *ObjectDiff) Kind() ChangeKind {
+	return d.change.Kind
+}
+
+// Path locates the change within the object, e.g. "Foo" for a field or
+// method, "params[1]" for the second parameter, or "Close.results[0]" for
+// the first result of method Close. It is empty if the change applies to
+// the object as a whole.
+func (d *ObjectDiff) Path() string {
+	return d.change.Path
+}
+
+// Reason explains the change in plain English.
+func (d *ObjectDiff) Reason() string {
+	return d.change.Msg
+}
+
+// Severity returns the severity of the change.
+func (d *ObjectDiff) Severity() Severity {
+	return d.severity
+}
+
+// Platforms returns the platforms, such as "linux/amd64", on which the
+// change was found, if packages were compared on several with Platforms
+// and the change was not found on all of them. It returns nil otherwise.
+func (d *ObjectDiff) Platforms() []string {
+	return d.platforms
+}
+
+// ComparePackages returns the changes introduced by package b
+// relative to package a. Compatible changes are only reported
+// with IncludeCompatible.
+//
+// Unexported types reachable from the exported API of a, such as the result
+// type of an exported function, are compared like exported types, except
+// for changes that only break composite literals, which other packages
+// cannot write for unexported types.
 //
-// A package can be passed as either a string or a map of string -> io.Reader.
-// If a string, it is the path to the package.
-// If a map, it maps filenames to source code.
-func ComparePackages(a, b interface{}) ([]*ObjectDiff, error) {
-	pkga, err := parseAndCheckPackage(a)
+// A package can be passed as either a string, a map of string -> io.Reader
+// or a Source. If a string, it is the path to the package, as with Dir. If
+// a map, it maps filenames to source code, as with Files. ComparePackages
+// returns an error for any other type.
+//
+// If a package passed as a string is part of a module, its imports are
+// resolved without network access at the versions required by the go.mod
+// file of the module: packages of the module itself are read from its
+// directory, and dependencies from its vendor directory or from the module
+// cache (GOMODCACHE). The same goes for a package read from another Source
+// with a go.mod file at its root. Otherwise, imports are resolved with
+// importer.Default.
+//
+// Files are selected by their build constraints, for the platform of
+// build.Default or those set with Platforms, and the tags set with
+// BuildTags.
+//
+// See Config.ComparePackages for a typed alternative.
+func ComparePackages(a, b interface{}, opts ...Option) ([]*ObjectDiff, error) {
+	sa, err := toSource(a)
 	if err != nil {
 		return nil, err
 	}
+	sb, err := toSource(b)
+	if err != nil {
+		return nil, err
+	}
+	return compareSourcePackages(sa, sb, newOptions(opts))
+}
 
-	pkgb, err := parseAndCheckPackage(b)
+// compareSourcePackages is like ComparePackages for packages a and b.
+func compareSourcePackages(a, b Source, o *options) ([]*ObjectDiff, error) {
+	fa, err := loadPackage(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := loadPackage(b)
 	if err != nil {
 		return nil, err
 	}
 
+	return o.eachPlatform(func(ctxt *build.Context) ([]*ObjectDiff, error) {
+		impa, impb := o.importer, o.importer
+		if impa == nil {
+			impa, err = moduleImporter(fa, ctxt)
+			if err != nil {
+				return nil, err
+			}
+			impb, err = moduleImporter(fb, ctxt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return comparePackages(fa, fb, "", impa, impb, ctxt, o)
+	})
+}
+
+// comparePackages is like ComparePackages for the files selected by ctxt.
+// If importPath is not empty, it is the import path of both packages;
+// otherwise, that of a is used. impa and impb resolve the imports of a and
+// b; if nil, the standard library is imported as built for ctxt.
+func comparePackages(a, b interface{}, importPath string, impa, impb types.Importer, ctxt *build.Context, o *options) ([]*ObjectDiff, error) {
+	pkga, erra := parseAndCheckPackage(a, importPath, impa, ctxt)
+	if cerr, ok := erra.(*CheckError); ok {
+		cerr.Old = true
+	}
+	if pkga == nil {
+		return nil, erra
+	}
+
+	// Share the import path so that named types match across versions.
+	pkgb, errb := parseAndCheckPackage(b, pkga.path, impb, ctxt)
+	if pkgb == nil {
+		return nil, errb
+	}
+
+	if err := o.typeErrors(erra, errb); err != nil {
+		return nil, err
+	}
+
+	cmp := o.cmp
+	cmp.Returned = typecmp.ReturnedInterfaces(pkga.types)
+
+	// Unexported types reachable from the API are compared like
+	// exported ones.
+	reachable := typecmp.ReachableTypes(pkga.types)
+
 	var diffs []*ObjectDiff
 	for _, name := range pkga.scope.Names() {
 		x := pkga.scope.Lookup(name)
-		if !x.Exported() {
+		if tn, _ := x.(*types.TypeName); !x.Exported() && !reachable[tn] {
 			continue
 		}
 		y := pkgb.scope.Lookup(name)
-		if typecmp.Compatible(x, y) {
+		if _, ok := y.(*types.TypeName); !ok && !x.Exported() {
+			// Users cannot name unexported types: removing one only
+			// breaks them through the exported objects referring to it.
+			continue
+		}
+		changes := typecmp.Compare(x, y, cmp)
+		if len(changes) == 0 {
 			continue
 		}
 		objx := &Object{x, pkga.fset, pkga.decls[name]}
 		objy := &Object{y, pkgb.fset, pkgb.decls[name]}
-		diffs = append(diffs, &ObjectDiff{objx, objy})
+		diffs = o.appendDiffs(diffs, pkga.path, objx, objy, changes)
+	}
+
+	if o.compatible {
+		for _, name := range pkgb.scope.Names() {
+			y := pkgb.scope.Lookup(name)
+			if !y.Exported() || pkga.scope.Lookup(name) != nil {
+				continue
+			}
+			objx := &Object{nil, pkga.fset, nil}
+			objy := &Object{y, pkgb.fset, pkgb.decls[name]}
+			diffs = o.appendDiffs(diffs, pkga.path, objx, objy, typecmp.Compare(nil, y, cmp))
+		}
 	}
 
 	return diffs, nil
 }
 
 type pkg struct {
+	types *types.Package
+	path  string
 	decls map[string]ast.Node
 	fset  *token.FileSet
 	scope *types.Scope
 }
 
-func parseAndCheckPackage(f interface{}) (*pkg, error) {
+// moduleImporter returns an importer for the module containing the
+// package f, passed as to parseAndCheckPackage, selecting files with ctxt,
+// or nil if f is neither a directory within a module nor a treeDir with
+// a go.mod file at the root of its tree.
+func moduleImporter(f interface{}, ctxt *build.Context) (types.Importer, error) {
+	if td, ok := f.(treeDir); ok {
+		if _, err := td.t.ReadFile("go.mod"); err != nil {
+			return nil, nil
+		}
+		return load.NewImporter(td.t, ctxt)
+	}
+	dir, ok := f.(string)
+	if !ok {
+		return nil, nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return load.NewImporter(load.Dir(dir), ctxt)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// parseAndCheckPackage parses and type-checks the files of package f,
+// either a directory or a treeDir, that match ctxt. If importPath is not
+// empty, it is the import path of the package; otherwise, the directory
+// of the package is used. Imports are resolved with imp or, if nil,
+// load.StdImporter.
+//
+// Syntax errors are returned as a *CheckError with a nil package. Type
+// errors are returned as a *CheckError along with the package, which
+// holds the partial type information found.
+func parseAndCheckPackage(f interface{}, importPath string, imp types.Importer, ctxt *build.Context) (*pkg, error) {
 	pkg := &pkg{
 		fset:  token.NewFileSet(),
 		decls: make(map[string]ast.Node),
@@ -139,10 +328,14 @@ func parseAndCheckPackage(f interface{}) (*pkg, error) {
 	case string:
 		path = ff
 		pkgs, err := parser.ParseDir(pkg.fset, path, func(info os.FileInfo) bool {
-			return !strings.HasSuffix(info.Name(), "_test.go")
+			if !isPackageFile(info.Name()) {
+				return false
+			}
+			ok, err := ctxt.MatchFile(path, info.Name())
+			return err == nil && ok
 		}, 0)
 		if err != nil {
-			return nil, err
+			return nil, syntaxError(importPath, path, err)
 		}
 		for _, p := range pkgs {
 			parsed = p
@@ -152,25 +345,43 @@ func parseAndCheckPackage(f interface{}) (*pkg, error) {
 			return nil, errors.New("no package found")
 		}
 
-	case map[string]io.Reader:
+	case treeDir:
+		path = ff.dir
+		names, err := ff.t.ReadDir(ff.dir)
+		if err != nil {
+			return nil, err
+		}
+		tctxt := load.BuildContext(ctxt, ff.t)
 		parsed = &ast.Package{Files: make(map[string]*ast.File)}
-		for filename, reader := range ff {
-			if !strings.HasSuffix(filename, ".go") || strings.HasSuffix(filename, "_test.go") {
+		for _, name := range names {
+			if !isPackageFile(name) {
 				continue
 			}
-			path = filepath.Dir(filename)
-			if src, err := parser.ParseFile(pkg.fset, filename, reader, 0); err == nil {
-				name := src.Name.Name
-				parsed.Name = name
-				parsed.Files[filename] = src
-			} else {
+			if ok, err := tctxt.MatchFile(ff.dir, name); err != nil || !ok {
+				continue
+			}
+			filename := name
+			if ff.dir != "." {
+				filename = ff.dir + "/" + name
+			}
+			data, err := ff.t.ReadFile(filename)
+			if err != nil {
 				return nil, err
 			}
+			src, err := parser.ParseFile(pkg.fset, filename, data, 0)
+			if err != nil {
+				return nil, syntaxError(importPath, ff.dir, err)
+			}
+			parsed.Name = src.Name.Name
+			parsed.Files[filename] = src
 		}
 
 	default:
 		panic(f)
 	}
+	if importPath != "" {
+		path = importPath
+	}
 
 	for _, f := range parsed.Files {
 		for name, obj := range f.Scope.Objects {
@@ -178,12 +389,20 @@ func parseAndCheckPackage(f interface{}) (*pkg, error) {
 		}
 	}
 
+	if len(parsed.Files) == 0 {
+		return nil, errors.New("no package found")
+	}
+
+	if imp == nil {
+		imp = load.StdImporter(ctxt)
+	}
+	var errs []error
 	conf := &types.Config{
 		Error: func(err error) {
-			fmt.Println("type checker:", err)
+			errs = append(errs, err)
 		},
 		IgnoreFuncBodies: true,
-		Importer:         importer.Default(),
+		Importer:         imp,
 	}
 
 	files := make([]*ast.File, 0, len(parsed.Files))
@@ -191,11 +410,29 @@ func parseAndCheckPackage(f interface{}) (*pkg, error) {
 		files = append(files, f)
 	}
 
-	checked, err := conf.Check(path, pkg.fset, files, nil)
-	if err != nil {
-		return nil, err
-	}
-
+	checked, _ := conf.Check(path, pkg.fset, files, nil)
+	pkg.types = checked
+	pkg.path = checked.Path()
 	pkg.scope = checked.Scope()
+	if len(errs) > 0 {
+		return pkg, &CheckError{Path: pkg.path, Errors: errs}
+	}
 	return pkg, nil
 }
+
+// syntaxError returns err, found while parsing the package with the given
+// import path, or in directory dir if empty, as a *CheckError.
+func syntaxError(importPath, dir string, err error) error {
+	if importPath == "" {
+		importPath = dir
+	}
+	cerr := &CheckError{Path: importPath}
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			cerr.Errors = append(cerr.Errors, e)
+		}
+	} else {
+		cerr.Errors = []error{err}
+	}
+	return cerr
+}
@@ -1,6 +1,20 @@
 package breaking
 
-import "testing"
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
 
 const (
 	dira = "testdata/a"
@@ -8,7 +22,7 @@ const (
 )
 
 func TestObjectDiffNew(t *testing.T) {
-	d := &ObjectDiff{nil, &Object{obj: nil}}
+	d := &ObjectDiff{b: &Object{obj: nil}}
 	n := d.New()
 	if n != nil {
 		t.Error("d.New(): expected nil, got", n)
@@ -17,15 +31,56 @@ func TestObjectDiffNew(t *testing.T) {
 
 func TestBreaking(t *testing.T) {
 	names := []string{
+		"AliasToDefined",
+		"AliasTypeChanged",
+		"ChanInterfaceParamWidened",
+		"ChanParamNarrowed",
+		"ChanParamWidened",
+		"ChanResultNarrowed",
+		"ChanResultWidened",
+		"ComparabilityLostArray",
+		"ComparabilityLostStruct",
+		"ConstUntypedToTyped",
+		"ConstraintNamedTightened",
+		"ConstValueChanged",
+		"DefinedToAliasLiteral",
+		"EmbeddedAmbiguous",
+		"EmbeddedPromotedRemoved",
 		"FuncParameterAdded",
+		"FuncParamNamedSwapped",
+		"FuncParamNamedToUnderlying",
 		"FuncParamTypeChanged",
 		"FuncResAdded",
 		"FuncRetTypeChanged",
+		"GenericConstraintTightened",
+		"GenericInstantiationChanged",
+		"GenericMethodsTightened",
+		"GenericTypeParamAdded",
+		"GenericTypeParamRemoved",
+		"GenericTypeTightened",
+		"GenericUnionTightened",
+		"InterfaceConstraintMethodAdded",
 		"InterfaceMethodAdded",
 		"InterfaceMethodDeleted",
 		"InterfaceMethodParameterAdded",
+		"InterfaceMethodsReplaced",
 		"InterfaceMethParamTypeChanged",
 		"InterfaceMethRetTypeChanged",
+		"InterfaceUnusedMethodAdded",
+		"KindConstToFunc",
+		"KindConstToVar",
+		"KindFuncToFuncVarTypeChanged",
+		"KindFuncVarToFunc",
+		"KindVarToConst",
+		"MethodPointerRemoved",
+		"MethodPromotedRemoved",
+		"MethodRemoved",
+		"MethodSignatureChanged",
+		"MethodValueToPointer",
+		"SourceInterfaceResultNarrowed",
+		"SourceParamBasicWidened",
+		"SourceParamNarrowed",
+		"SourceResultConcrete",
 		"StructExportedAddedUnexported",
 		"StructExportedPrependedExported",
 		"StructExportedRemoved",
@@ -37,6 +92,7 @@ func TestBreaking(t *testing.T) {
 		"VarDeleted",
 		"VarToFunc",
 		"VarTypeChanged",
+		"unexportedReachable",
 	}
 
 	diffs, err := ComparePackages(dira, dirb)
@@ -58,11 +114,212 @@ func TestBreaking(t *testing.T) {
 	}
 }
 
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		kind ChangeKind
+		path string
+	}{
+		{"VarDeleted", Removed, ""},
+		{"VarToFunc", VarBecameFunc, ""},
+		{"TypeStructToVar", TypeBecameVar, ""},
+		{"KindConstToVar", ConstBecameVar, ""},
+		{"KindConstToFunc", ConstBecameFunc, ""},
+		{"KindVarToConst", VarBecameConst, ""},
+		{"KindFuncVarToFunc", VarBecameFunc, ""},
+		{"KindFuncToFuncVarTypeChanged", ParamTypeChanged, "params[0]"},
+		{"VarTypeChanged", TypeChanged, ""},
+		{"FuncParameterAdded", ParamAdded, "params[0]"},
+		{"FuncParamTypeChanged", ParamTypeChanged, "params[0]"},
+		{"FuncResAdded", ResultAdded, "results[0]"},
+		{"FuncRetTypeChanged", ResultTypeChanged, "results[0]"},
+		{"InterfaceMethParamTypeChanged", ParamTypeChanged, "Foo.params[0]"},
+		{"InterfaceMethodAdded", MethodAdded, "Bar"},
+		{"InterfaceMethodDeleted", MethodRemoved, "Foo"},
+		{"InterfaceMethodsReplaced", MethodRemoved, "Bar"},
+		{"InterfaceMethodsReplaced", MethodAdded, "Baz"},
+		{"InterfaceMethodsReplaced", ParamTypeChanged, "Foo.params[0]"},
+		{"InterfaceUnusedMethodAdded", MethodAdded, "Bar"},
+		{"InterfaceConstraintMethodAdded", MethodAdded, "Bar"},
+		{"StructExportedRemoved", FieldRemoved, "Foo"},
+		{"StructExportedTypeChanged", FieldTypeChanged, "Foo"},
+		{"StructExportedRepositioned", FieldRepositioned, "Foo"},
+		{"StructExportedAddedUnexported", UnexportedFieldAdded, "foo"},
+		{"MethodRemoved", MethodRemoved, "Close"},
+		{"MethodSignatureChanged", ResultRemoved, "Close.results[0]"},
+		{"MethodValueToPointer", MethodReceiverChanged, "Close"},
+		{"ConstValueChanged", ConstValueChanged, ""},
+		{"ConstUntypedToTyped", ConstBecameTyped, ""},
+		{"GenericTypeParamAdded", TypeParamAdded, "typeparams[1]"},
+		{"GenericTypeParamRemoved", TypeParamRemoved, "typeparams[1]"},
+		{"GenericConstraintTightened", ConstraintTightened, "typeparams[0]"},
+		{"GenericTypeTightened", ConstraintTightened, "typeparams[0]"},
+		{"GenericInstantiationChanged", FieldTypeChanged, "Foo"},
+		{"FuncParamNamedToUnderlying", ParamTypeChanged, "params[0]"},
+		{"FuncParamNamedSwapped", ParamTypeChanged, "params[0]"},
+		{"EmbeddedPromotedRemoved", FieldRemoved, "Foo"},
+		{"EmbeddedPromotedRemoved", MethodRemoved, "Close"},
+		{"EmbeddedAmbiguous", SelectorAmbiguous, "Foo"},
+		{"EmbeddedAmbiguous", SelectorAmbiguous, "Close"},
+		{"ComparabilityLostStruct", ComparabilityLost, ""},
+		{"ComparabilityLostArray", ComparabilityLost, ""},
+		{"ChanParamNarrowed", ChanDirChanged, "params[0]"},
+		{"ChanResultNarrowed", ChanDirChanged, "results[0]"},
+		{"ChanInterfaceParamWidened", ChanDirChanged, "Foo.params[0]"},
+		{"AliasTypeChanged", TypeChanged, ""},
+		{"ConstraintNamedTightened", ConstraintTightened, ""},
+		{"DefinedToAliasLiteral", DefinedBecameAlias, ""},
+		{"AliasToDefined", AliasBecameDefined, ""},
+		{"unexportedReachable", FieldRemoved, "Foo"},
+		{"unexportedReachable", FieldTypeChanged, "Bar"},
+		{"unexportedReachable", MethodRemoved, "Close"},
+	}
+
+	diffs, err := ComparePackages(dira, dirb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, d := range diffs {
+			if d.Name() == tt.name && d.Kind() == tt.kind && d.Path() == tt.path {
+				found = true
+				if d.Reason() == "" {
+					t.Errorf("%s: empty reason", tt.name)
+				}
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: no %v change at %q", tt.name, tt.kind, tt.path)
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	severityOf := func(diffs []*ObjectDiff, name string) Severity {
+		for _, d := range diffs {
+			if d.Name() == name {
+				return d.Severity()
+			}
+		}
+		t.Fatalf("%s: not reported", name)
+		return 0
+	}
+
+	diffs, err := ComparePackages(dira, dirb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := severityOf(diffs, "ConstValueChanged"); s != Warning {
+		t.Errorf("ConstValueChanged: expected %v, got %v", Warning, s)
+	}
+	if s := severityOf(diffs, "ConstUntypedToTyped"); s != Breaking {
+		t.Errorf("ConstUntypedToTyped: expected %v, got %v", Breaking, s)
+	}
+
+	diffs, err = ComparePackages(dira, dirb, WithSeverity(ConstValueChanged, Breaking))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := severityOf(diffs, "ConstValueChanged"); s != Breaking {
+		t.Errorf("ConstValueChanged with WithSeverity: expected %v, got %v", Breaking, s)
+	}
+
+	diffs, err = ComparePackages(dira, dirb, WithSeverity(ConstBecameVar, Warning))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := severityOf(diffs, "KindConstToVar"); s != Warning {
+		t.Errorf("KindConstToVar with WithSeverity: expected %v, got %v", Warning, s)
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		name string
+		kind ChangeKind
+		path string
+	}{
+		{"FuncAdded", Added, ""},
+		{"StructExportedAppendedExported", FieldAdded, "Bar"},
+		{"StructEmptyAddedExported", FieldAdded, "Foo"},
+		{"MethodAdded", MethodAddedToType, "Close"},
+		{"GenericConstraintLoosened", ConstraintLoosened, "typeparams[0]"},
+		{"ConstraintNamedLoosened", ConstraintLoosened, ""},
+		{"AliasStructFieldAdded", FieldAdded, "Bar"},
+		{"InterfaceSealedMethodAdded", MethodAdded, "Bar"},
+		{"EmbeddedPromotedAdded", FieldAdded, "EmbeddedInner"},
+		{"EmbeddedPromotedAdded", FieldAdded, "Foo"},
+		{"EmbeddedPromotedAdded", MethodAddedToType, "Close"},
+		{"InterfaceReturnedMethodAdded", MethodAdded, "Bar"},
+		{"DefinedToAlias", DefinedBecameAlias, ""},
+		{"KindFuncToFuncVar", FuncBecameVar, ""},
+	}
+
+	diffs, err := ComparePackages(dira, dirb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		if d.Severity() == Compatible {
+			t.Errorf("%s: compatible change reported without IncludeCompatible", d.Name())
+		}
+	}
+
+	diffs, err = ComparePackages(dira, dirb, IncludeCompatible())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		found := false
+		for _, d := range diffs {
+			if d.Name() == tt.name && d.Kind() == tt.kind && d.Path() == tt.path {
+				found = true
+				if d.Severity() != Compatible {
+					t.Errorf("%s: expected %v, got %v", tt.name, Compatible, d.Severity())
+				}
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: no %v change at %q", tt.name, tt.kind, tt.path)
+		}
+	}
+}
+
 func TestNonBreaking(t *testing.T) {
 	names := []string{
+		"AliasGenericUnchanged",
+		"AliasStructFieldAdded",
+		"AliasTarget",
+		"AliasUnchanged",
+		"ComparabilityKept",
+		"ConstTypedUnchanged",
+		"ConstraintNamedLoosened",
+		"ConstUnchanged",
+		"DefinedToAlias",
+		"EmbeddedPromotedAdded",
+		"FuncParamDefinedToAlias",
+		"FuncParamNamedUnchanged",
 		"FuncParamRenamed",
 		"FuncResRenamed",
 		"FuncResStructUnexportedNotIdentical",
+		"GenericBox",
+		"GenericConstraintLoosened",
+		"GenericInstantiationUnchanged",
+		"GenericMethodsLoosened",
+		"GenericTypeLoosened",
+		"GenericTypeParamRenamed",
+		"GenericUnionLoosened",
+		"InterfaceReturnedMethodAdded",
+		"InterfaceSealedMethodAdded",
+		"KindFuncToFuncVar",
+		"MethodAdded",
+		"MethodPointerToValue",
+		"MethodReceiverRenamed",
+		"MethodUnexportedRemoved",
 		"NamedType",
 		"StructEmptyAddedExported",
 		"StructEmptyAddedUnexported",
@@ -77,6 +334,8 @@ func TestNonBreaking(t *testing.T) {
 		"StructUnexportedRemoved",
 		"StructUnexportedRepositioned",
 		"TestDeletedIgnored",
+		"unexportedLiteral",
+		"unexportedUnreachable",
 	}
 
 	diffs, err := ComparePackages(dira, dirb)
@@ -97,3 +356,548 @@ func TestNonBreaking(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildTags(t *testing.T) {
+	for _, tags := range [][]string{nil, {"breakingtag"}} {
+		diffs, err := ComparePackages(dira, dirb, BuildTags(tags...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed := false
+		for _, d := range diffs {
+			removed = removed || d.Name() == "BuildTagged" && d.Kind() == Removed
+		}
+		if want := tags != nil; removed != want {
+			t.Errorf("tags %v: BuildTagged removed: expected %v, got %v", tags, want, removed)
+		}
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	diffs, err := ComparePackages(dira, dirb, Platforms("linux/amd64", "windows/amd64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, d := range diffs {
+		if d.Name() != "PlatformSplit" {
+			if d.Platforms() != nil {
+				t.Errorf("%s: expected a change on all platforms, got %v", d.Name(), d.Platforms())
+			}
+			continue
+		}
+		found = true
+		if p := d.Platforms(); strings.Join(p, ",") != "windows/amd64" {
+			t.Errorf("PlatformSplit: expected a change on windows/amd64 only, got %v", p)
+		}
+	}
+	if !found {
+		t.Error("PlatformSplit: not reported")
+	}
+
+	single, err := ComparePackages(dira, dirb, Platforms("linux/amd64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != len(diffs)-1 {
+		t.Errorf("expected changes found on both platforms to be merged: %d changes on linux/amd64, %d on both", len(single), len(diffs))
+	}
+
+	if _, err := ComparePackages(dira, dirb, Platforms("linux")); err == nil {
+		t.Error("invalid platform: expected an error")
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	const (
+		dira = "testdata/typeerrors/a"
+		dirb = "testdata/typeerrors/b"
+	)
+
+	_, err := ComparePackages(dira, dirb)
+	var cerr *CheckError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected a *CheckError, got %v", err)
+	}
+	if cerr.Old {
+		t.Error("errors attributed to the old version")
+	}
+	if len(cerr.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", cerr.Errors)
+	}
+	if terr, ok := cerr.Errors[0].(types.Error); !ok || !terr.Pos.IsValid() {
+		t.Errorf("expected a types.Error with a position, got %#v", cerr.Errors[0])
+	}
+
+	var handled []*CheckError
+	diffs, err := ComparePackages(dira, dirb, AllowTypeErrors(func(err *CheckError) {
+		handled = append(handled, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 1 || handled[0].Old {
+		t.Errorf("expected the errors of the new version to be handled, got %v", handled)
+	}
+	if len(diffs) != 1 || diffs[0].Name() != "Changed" {
+		t.Errorf("expected a change to Changed, got %v", diffs)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	want, err := ComparePackages(dira, dirb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := new(Config).ComparePackages(Dir(dira), Dir(dirb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != len(want) {
+		t.Errorf("zero Config: expected %d changes, got %d", len(want), len(diffs))
+	}
+
+	files := make(Files)
+	for _, name := range []string{"a.go", "a_linux.go", "a_windows.go"} {
+		data, err := os.ReadFile(filepath.Join(dira, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[name] = data
+	}
+	conf := &Config{
+		Strictness: Strict,
+		Disabled:   map[ChangeKind]bool{TypeChanged: true},
+	}
+	diffs, err = conf.ComparePackages(files, Dir(dirb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		if d.Kind() == TypeChanged {
+			t.Errorf("%s: disabled %v change reported", d.Name(), d.Kind())
+		}
+		if d.Name() == "ConstValueChanged" && d.Severity() != Breaking {
+			t.Errorf("ConstValueChanged: expected %v with Strict, got %v", Breaking, d.Severity())
+		}
+	}
+
+	if _, err := ComparePackages(1, dirb); err == nil {
+		t.Error("unsupported source: expected an error")
+	}
+}
+
+func TestCompareTrees(t *testing.T) {
+	type change struct {
+		pkg, name string
+		kind      ChangeKind
+	}
+	want := []change{
+		{"example.com/tree/added", "", PackageAdded},
+		{"example.com/tree/kept", "Changed", ParamTypeChanged},
+		{"example.com/tree/removed", "", PackageRemoved},
+	}
+
+	modCache, err := filepath.Abs("testdata/modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOMODCACHE", modCache)
+
+	a, err := readTree("testdata/tree/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := readTree("testdata/tree/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zipb, err := zipTree("testdata/tree/b", "example.com/tree@v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trees := [][2]interface{}{
+		{"testdata/tree/a", "testdata/tree/b"},
+		{a, b},
+		{FS(os.DirFS("testdata/tree/a")), zipb},
+	}
+	if _, err := exec.LookPath("git"); err == nil {
+		gita, gitb := gitTrees(t, "testdata/tree/a", "testdata/tree/b")
+		trees = append(trees, [2]interface{}{gita, gitb})
+	}
+
+	for _, trees := range trees {
+		diffs, err := CompareTrees(trees[0], trees[1], IncludeCompatible())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []change
+		for _, d := range diffs {
+			got = append(got, change{d.Package(), d.Name(), d.Kind()})
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%T: expected %v, got %v", trees[0], want, got)
+		}
+		for _, c := range want {
+			found := false
+			for _, g := range got {
+				found = found || g == c
+			}
+			if !found {
+				t.Errorf("%T: %v not reported", trees[0], c)
+			}
+		}
+	}
+}
+
+// gitTrees commits the files of dira and then those of dirb to a new Git
+// repository, below a subdirectory as for a module that is not at the root
+// of its repository, and returns the GitTree sources of both commits.
+func gitTrees(t *testing.T, dira, dirb string) (a, b Source) {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, out)
+		}
+	}
+	git("init", "-q")
+	dir := filepath.Join(repo, "tree")
+	for _, src := range []string{dira, dirb} {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyDir(dir, src); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false",
+			"commit", "-q", "-m", src)
+	}
+	return GitTree(dir, "HEAD~1"), GitTree(dir, "HEAD")
+}
+
+// copyDir copies the files below src to dst.
+func copyDir(dst, src string) error {
+	return filepath.WalkDir(src, func(name string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if e.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
+
+// zipTree returns a Zip source for the files below dir, archived
+// below prefix.
+func zipTree(dir, prefix string) (Source, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(name string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		f, err := w.Create(prefix + "/" + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		return nil, err
+	}
+	return Zip(r, prefix)
+}
+
+func TestSources(t *testing.T) {
+	a := fstest.MapFS{
+		"pkg/p.go":            {Data: []byte("package p\n\nfunc F(int) {}\n")},
+		"testdata/ignored.go": {Data: []byte("package ignored\n")},
+	}
+	b := Files{"p.go": []byte("package p\n\nfunc F(string) {}\n")}
+	diffs, err := ComparePackages(FS(a), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Name() != "F" || diffs[0].Kind() != ParamTypeChanged {
+		t.Errorf("expected a %v change of F, got %v", ParamTypeChanged, diffs)
+	}
+
+	a["other/q.go"] = &fstest.MapFile{Data: []byte("package q\n")}
+	if _, err := ComparePackages(FS(a), b); err == nil {
+		t.Error("several packages: expected an error")
+	}
+
+	src, err := zipTree("testdata/tree/b", "example.com/tree@v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := src.ModulePath(); p != "example.com/tree" {
+		t.Errorf("Zip: expected module path example.com/tree, got %q", p)
+	}
+}
+
+func TestSourceCompatible(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   ChangeKind
+		relaxed  ChangeKind
+		severity Severity
+	}{
+		{"SourceVariadicAdded", VariadicChanged, VariadicParamAdded, Compatible},
+		{"SourceParamWidened", ParamTypeChanged, ParamTypeWidened, Compatible},
+		{"SourceResultNarrowed", ResultTypeChanged, ResultTypeNarrowed, Compatible},
+		{"SourceParamNarrowed", ParamTypeChanged, ParamTypeChanged, Breaking},
+		{"SourceParamBasicWidened", ParamTypeChanged, ParamTypeChanged, Breaking},
+		{"SourceResultConcrete", ResultTypeChanged, ResultTypeChanged, Breaking},
+		{"SourceInterfaceResultNarrowed", ResultTypeChanged, ResultTypeChanged, Breaking},
+		{"ChanParamWidened", ChanDirChanged, ChanDirChanged, Compatible},
+		{"ChanResultWidened", ChanDirChanged, ChanDirChanged, Compatible},
+		{"ChanParamNarrowed", ChanDirChanged, ChanDirChanged, Breaking},
+	}
+
+	strict, err := ComparePackages(dira, dirb, IncludeCompatible())
+	if err != nil {
+		t.Fatal(err)
+	}
+	relaxed, err := ComparePackages(dira, dirb, IncludeCompatible(), SourceCompatible())
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := func(diffs []*ObjectDiff, name string, kind ChangeKind) *ObjectDiff {
+		for _, d := range diffs {
+			if d.Name() == name && d.Kind() == kind {
+				return d
+			}
+		}
+		return nil
+	}
+	for _, tt := range tests {
+		if d := find(strict, tt.name, tt.strict); d == nil || d.Severity() != Breaking {
+			t.Errorf("%s: no breaking %v change", tt.name, tt.strict)
+		}
+		if d := find(relaxed, tt.name, tt.relaxed); d == nil || d.Severity() != tt.severity {
+			t.Errorf("%s: no %v %v change with SourceCompatible", tt.name, tt.severity, tt.relaxed)
+		}
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		want []string // paths of the changes to StructTagsChanged
+	}{
+		{nil, []string{"Address.City", "Age", "ID"}},
+		{[]Option{StructTags("db")}, []string{"Name"}},
+		{[]Option{StructTags()}, nil},
+	}
+	for _, tt := range tests {
+		diffs, err := ComparePackages(dira, dirb, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diffs {
+			if d.Name() != "StructTagsChanged" {
+				continue
+			}
+			if d.Kind() != TagChanged || d.Severity() != Serialization {
+				t.Errorf("StructTagsChanged: unexpected %v %v change", d.Severity(), d.Kind())
+			}
+			got = append(got, d.Path())
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%d options: expected changes at %v, got %v", len(tt.opts), tt.want, got)
+		}
+	}
+}
+
+func TestSeparateUnkeyedLiterals(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"StructExportedAppendedExported", "Bar"},
+		{"StructExportedAddedUnexported", "foo"},
+		{"StructExportedRepositioned", "Foo"},
+	}
+
+	diffs, err := ComparePackages(dira, dirb, SeparateUnkeyedLiterals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		found := false
+		for _, d := range diffs {
+			if d.Name() != tt.name {
+				continue
+			}
+			switch d.Kind() {
+			case UnkeyedLiteralBroken:
+				found = found || d.Path() == tt.path
+				if d.Severity() != Warning {
+					t.Errorf("%s: expected %v, got %v", tt.name, Warning, d.Severity())
+				}
+			case UnexportedFieldAdded, FieldRepositioned:
+				t.Errorf("%s: %v change reported with SeparateUnkeyedLiterals", tt.name, d.Kind())
+			}
+		}
+		if !found {
+			t.Errorf("%s: no %v change at %q", tt.name, UnkeyedLiteralBroken, tt.path)
+		}
+	}
+}
+
+func TestLenientNamedTypes(t *testing.T) {
+	diffs, err := ComparePackages(dira, dirb, LenientNamedTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		switch d.Name() {
+		case "FuncParamNamedToUnderlying", "FuncParamNamedSwapped":
+			t.Errorf("%s: reported with LenientNamedTypes: %s", d.Name(), d.Reason())
+		}
+	}
+
+	// Type example.com/dep.Value changed from int to string
+	// between the versions of the dependency.
+	modCache, err := filepath.Abs("testdata/modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOMODCACHE", modCache)
+	for _, opts := range [][]Option{nil, {LenientNamedTypes()}} {
+		diffs, err := CompareTrees("testdata/tree/a", "testdata/tree/b", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, d := range diffs {
+			found = found || d.Name() == "UsesDep"
+		}
+		if lenient := opts != nil; found != lenient {
+			t.Errorf("UsesDep reported: %v, with LenientNamedTypes: %v", found, lenient)
+		}
+	}
+}
+
+// readTree reads the files below root into a map of slash-separated paths
+// relative to root to file contents.
+func readTree(root string) (map[string]io.Reader, error) {
+	files := make(map[string]io.Reader)
+	err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = bytes.NewReader(data)
+		return nil
+	})
+	return files, err
+}
+
+func TestNextVersion(t *testing.T) {
+	major := []*ObjectDiff{{severity: Breaking}}
+	minor := []*ObjectDiff{{severity: Compatible}, {severity: Warning}}
+	patch := []*ObjectDiff{{severity: Warning}}
+
+	tests := []struct {
+		current    string
+		modulePath string
+		diffs      []*ObjectDiff
+		want       string
+	}{
+		{"v1.2.3", "example.com/m", major, "v2.0.0"},
+		{"v1.2.3", "example.com/m", minor, "v1.3.0"},
+		{"v1.2.3", "example.com/m", patch, "v1.2.4"},
+		{"v1.2.3", "example.com/m", nil, "v1.2.4"},
+		{"v0.2.3", "example.com/m", major, "v0.3.0"},
+		{"v0.2.3", "example.com/m", minor, "v0.2.4"},
+		{"v2.1.0", "example.com/m/v2", major, "v3.0.0"},
+		{"v2.1.0", "example.com/m/v2", minor, "v2.2.0"},
+		{"v2.0.0-rc.1", "example.com/m/v2", major, "v2.0.0"},
+		{"v1.2.0-rc.1", "", minor, "v1.2.0"},
+		{"v1.2.1-rc.1", "", minor, "v1.3.0"},
+		{"v1.2.3+build", "", patch, "v1.2.4"},
+		{"v3.0.1", "gopkg.in/yaml.v3", major, "v4.0.0"},
+		{"v1.0.0", "gopkg.in/check.v1", minor, "v1.1.0"},
+		{"v0.1.0", "gopkg.in/user/pkg.v0", minor, "v0.1.1"},
+		{"v1.2.3", "example.com/m.v3", minor, "v1.3.0"},
+	}
+	for _, tt := range tests {
+		got, err := NextVersion(tt.current, tt.modulePath, tt.diffs)
+		if err != nil {
+			t.Errorf("NextVersion(%q, %q): %v", tt.current, tt.modulePath, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NextVersion(%q, %q): expected %s, got %s", tt.current, tt.modulePath, tt.want, got)
+		}
+	}
+
+	for _, tt := range []struct{ current, modulePath string }{
+		{"v2.0.0", "example.com/m"},
+		{"v1.0.0", "example.com/m/v2"},
+		{"1.0.0", "example.com/m"},
+		{"v1.0", "example.com/m"},
+		{"v1.01.0", "example.com/m"},
+		{"v3.0.0", "gopkg.in/yaml.v2"},
+	} {
+		if v, err := NextVersion(tt.current, tt.modulePath, nil); err == nil {
+			t.Errorf("NextVersion(%q, %q): expected error, got %s", tt.current, tt.modulePath, v)
+		}
+	}
+
+	for _, tt := range []struct{ modulePath, version, want string }{
+		{"example.com/m", "v1.0.0", "example.com/m"},
+		{"example.com/m", "v2.0.0", "example.com/m/v2"},
+		{"example.com/m/v2", "v3.0.0", "example.com/m/v3"},
+		{"example.com/m/v2", "v1.5.0", "example.com/m"},
+		{"gopkg.in/yaml.v3", "v4.0.0", "gopkg.in/yaml.v4"},
+		{"gopkg.in/check.v1", "v1.2.0", "gopkg.in/check.v1"},
+	} {
+		got, err := VersionModulePath(tt.modulePath, tt.version)
+		if err != nil || got != tt.want {
+			t.Errorf("VersionModulePath(%q, %q): expected %s, got %s, %v", tt.modulePath, tt.version, tt.want, got, err)
+		}
+	}
+}
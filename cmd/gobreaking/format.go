@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sprt/breaking"
+)
+
+func writeText(w io.Writer, diffs []*breaking.ObjectDiff) error {
+	for _, d := range diffs {
+		line := qualifiedName(d) + ": " + d.Reason()
+		if d.Severity() != breaking.Breaking {
+			line += " (" + d.Severity().String() + ")"
+		}
+		if p := d.Platforms(); p != nil {
+			line += " [" + strings.Join(p, ", ") + " only]"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qualifiedName returns the name of the object changed by d, qualified by
+// its import path unless it is in the root package of a tree without go.mod.
+func qualifiedName(d *breaking.ObjectDiff) string {
+	switch {
+	case d.Name() == "":
+		return d.Package()
+	case d.Package() == ".":
+		return d.Name()
+	}
+	return d.Package() + "." + d.Name()
+}
+
+// The types below define the JSON schema documented in the package comment.
+
+type jsonReport struct {
+	Changes []jsonChange `json:"changes"`
+}
+
+type jsonChange struct {
+	Package   string      `json:"package"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind"`
+	Path      string      `json:"path"`
+	Severity  string      `json:"severity"`
+	Message   string      `json:"message"`
+	Platforms []string    `json:"platforms,omitempty"`
+	Old       *jsonObject `json:"old,omitempty"`
+	New       *jsonObject `json:"new,omitempty"`
+}
+
+type jsonObject struct {
+	Decl string  `json:"decl"`
+	Pos  jsonPos `json:"pos"`
+}
+
+type jsonPos struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func writeJSON(w io.Writer, diffs []*breaking.ObjectDiff) error {
+	report := jsonReport{Changes: make([]jsonChange, 0, len(diffs))}
+	for _, d := range diffs {
+		report.Changes = append(report.Changes, jsonChange{
+			Package:   d.Package(),
+			Name:      d.Name(),
+			Kind:      d.Kind().String(),
+			Path:      d.Path(),
+			Severity:  d.Severity().String(),
+			Message:   d.Reason(),
+			Platforms: d.Platforms(),
+			Old:       newJSONObject(d.Old()),
+			New:       newJSONObject(d.New()),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func newJSONObject(obj *breaking.Object) *jsonObject {
+	if obj == nil {
+		return nil
+	}
+	pos := obj.Fpos()
+	return &jsonObject{
+		Decl: obj.String(),
+		Pos: jsonPos{
+			Filename: relFilename(pos.Filename),
+			Line:     pos.Line,
+			Column:   pos.Column,
+		},
+	}
+}
+
+// relFilename returns filename, slash-separated, relative to the current
+// directory if it is an absolute path within it. Files read from a Git tree
+// already are, so that all the objects of a report are located alike.
+func relFilename(filename string) string {
+	if filepath.IsAbs(filename) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+				filename = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filename)
+}
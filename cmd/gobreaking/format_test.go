@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sprt/breaking"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestWriteJSON(t *testing.T) {
+	old := breaking.Files{
+		"p.go": []byte("package p\n\nfunc F(i int) {}\n\nfunc Removed() {}\n"),
+	}
+	// Objects read from a directory have absolute filenames, which are
+	// written relative to the current directory.
+	dir, err := filepath.Abs("testdata/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &breaking.Config{IncludeCompatible: true}
+	diffs, err := conf.ComparePackages(old, breaking.Dir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, diffs); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "json.golden")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("writeJSON: got\n%s\nwant\n%s", buf.Bytes(), want)
+	}
+}
@@ -1,61 +1,26 @@
 package git
 
 import (
-	"bytes"
-	"io"
 	"os/exec"
+	"regexp"
 	"strings"
 )
 
-type kind int
+// releaseTag matches the tags of release versions, such as v1.2.3.
+var releaseTag = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
 
-const (
-	blob kind = iota
-	tree
-)
-
-type treeEntry struct {
-	filename string
-	kind     kind
-}
-
-type Tree struct {
-	treeish string
-	entries []treeEntry
-}
-
-func LsTree(treeish string) (*Tree, error) {
-	out, err := exec.Command("git", "ls-tree", treeish).Output()
+// LatestVersionTag returns the highest release version tag, such as v1.2.3,
+// reachable from treeish. Pre-release versions are ignored.
+// It returns the empty string if there is none.
+func LatestVersionTag(treeish string) (string, error) {
+	out, err := exec.Command("git", "tag", "--list", "--merged", treeish, "--sort=-v:refname", "v*").Output()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	var entries []treeEntry
-	for _, line := range strings.Split(string(out)[:len(out)-1], "\n") {
-		fields := strings.Fields(line)
-		var k kind
-		if fields[1] == "tree" {
-			k = tree
-		} else {
-			k = blob
-		}
-		entries = append(entries, treeEntry{filename: fields[3], kind: k})
-	}
-
-	return &Tree{treeish, entries}, nil
-}
-
-func (t *Tree) GoFiles() (map[string]io.Reader, error) {
-	files := make(map[string]io.Reader)
-	for _, entry := range t.entries {
-		if entry.kind != blob || !strings.HasSuffix(entry.filename, ".go") {
-			continue
-		}
-		b, err := exec.Command("git", "show", t.treeish+":"+entry.filename).Output()
-		if err != nil {
-			return nil, err
+	for _, tag := range strings.Split(string(out), "\n") {
+		if releaseTag.MatchString(tag) {
+			return tag, nil
 		}
-		files[entry.filename] = bytes.NewReader(b)
 	}
-	return files, nil
+	return "", nil
 }
@@ -1,22 +1,116 @@
 // gobreaking reports breaking changes in a Git repository.
 //
 // Below is the exhaustive list of changes that are considered breaking.
-// It applies exclusively to exposed names.
-//
-//  - Removing a name (constant, type, variable, function).
-//  - Changing the kind of a name.
-//  - Adding or removing a method in an interface.
-//  - Adding or removing a parameter in a function or interface.
-//  - Changing the type of a parameter or result in a function or interface.
-//  - Adding or removing a result in a function or interface.
-//  - Changing the type of an exported struct field.
-//  - Removing an exported field from a struct.
-//  - Adding an unexported field to a struct containing only exported fields.
-//  - Adding an exported field before the last field of a struct
-//    containing only exported fields.
-//  - Repositioning a field in a struct containing only exported fields.
-//
-// gobreaking can be invoked two ways:
+// It applies exclusively to exposed names, and to the exported fields and
+// methods of the unexported types they refer to, such as the result type
+// of an exported function.
+//
+//   - Removing a name (constant, type, variable, function).
+//   - Changing the kind of a name, e.g. a variable into a function, except
+//     a function into a variable of the same type.
+//   - Changing the type of a variable or constant, or the underlying type
+//     of a type.
+//   - Adding a method to an interface, which breaks its implementations,
+//     or removing one, which breaks its callers.
+//   - Adding or removing a parameter in a function or interface.
+//   - Changing the type of a parameter or result in a function or interface.
+//   - Adding or removing a result in a function or interface.
+//   - Making a function variadic, or no longer variadic.
+//   - Changing the direction of a channel parameter or result. With
+//     -source-compatible, only restricting the direction of a channel
+//     parameter, e.g. from <-chan T to chan T, or of a channel result, e.g.
+//     from chan T to <-chan T, and changing the direction of a channel in
+//     an interface method are breaking.
+//   - Changing the type of an exported struct field.
+//   - Removing an exported field from a struct.
+//   - Removing an exported field promoted from an embedded field, or changing
+//     its type.
+//   - Embedding a field that makes a promoted field or method ambiguous.
+//   - Adding an unexported field to a struct containing only exported fields.
+//   - Adding an exported field before the last field of a struct
+//     containing only exported fields.
+//   - Repositioning a field in a struct containing only exported fields.
+//   - Removing an exported method from a type, including a promoted method.
+//   - Changing the signature of an exported method.
+//   - Moving an exported method from a value receiver to a pointer receiver.
+//   - Changing an untyped constant into a typed constant.
+//   - Making a type incomparable, e.g. by adding a slice, map or func field
+//     to a struct, as it can no longer be used with == or as a map key.
+//   - Adding or removing a type parameter in a generic function or type.
+//   - Tightening the constraint of a type parameter, or the type set of
+//     a constraint interface, so that a type argument that satisfied the old
+//     constraint no longer satisfies the new one.
+//   - Turning a defined type into an alias for a type that is not a defined
+//     type, such as a type literal.
+//   - Turning an alias into a defined type.
+//
+// Adding a method to an interface is compatible if the interface has an
+// unexported method, so that it cannot be implemented by other packages,
+// or if the package returns the interface but never accepts it, not even
+// as a type parameter constraint, so that users have no reason to implement
+// it.
+//
+// Types are identical if they are spelled the same way. In particular,
+// named types are identical only if they have the same name and import
+// path: changing a parameter from time.Duration to int64 is breaking.
+//
+// Turning a defined type into an alias for a defined type with the same
+// structure and methods, as when moving a type to another package,
+// is compatible.
+//
+// Changing a constant into a variable only breaks constant expressions
+// using it, such as array lengths. The -const-to-var flag sets the severity
+// of this change: breaking (the default), warning or compatible.
+//
+// Changing the value of a constant is reported as a warning, or as a
+// breaking change if the -strict-consts flag is set.
+//
+// Adding an unexported field to or repositioning a field of a struct with
+// only exported fields only breaks unkeyed composite literals of the struct,
+// such as T{1, 2}, as does adding an exported field. With the
+// -unkeyed-literals flag, these changes are reported as warnings.
+//
+// Changing the struct tag of an exported field for the json, xml, yaml or
+// protobuf encodings is reported as a serialization change: it does not
+// break compilation, but values are no longer encoded in the same way.
+// The -struct-tags flag sets the tag keys to compare, or none if empty.
+//
+// Every package in the current directory and its subdirectories is compared,
+// as with the ./... pattern of the go command, except those that other
+// modules cannot import: internal packages and commands (package main).
+// Removing a package is a breaking change.
+//
+// With the -source-compatible flag, only changes to functions and methods
+// that break calls to them are reported: adding a final variadic parameter,
+// changing a parameter of a non-basic type to an interface that the type
+// implements, changing an interface result to an interface that has all of
+// its methods, and widening the direction of a channel parameter or result
+// are then compatible. These changes still break code using a function as a
+// value, or a method to implement an interface. Methods of interfaces are
+// always compared strictly.
+//
+// Files are selected by their build constraints, such as //go:build lines
+// and _linux.go suffixes, for the current platform and the tags set with
+// the -tags flag. The -platforms flag instead compares the packages as
+// built for each of a comma-separated list of platforms, such as
+// linux/amd64,windows/amd64. A change found on only some of them is
+// followed by the list of these platforms.
+//
+// Syntax and type errors in either version of a package are printed on
+// standard error, along with the version they were found in, and stop the
+// comparison. With the -allow-type-errors flag, type errors are printed as
+// warnings and packages are compared with the partial type information
+// found, so that changes to the declarations involved may be missed.
+//
+// If the current directory is the root of a module, each revision is
+// type-checked against the dependency versions required by its own go.mod
+// file, read from the module cache. The network is never accessed.
+//
+// gobreaking can be invoked three ways:
+//
+// Without arguments: it reports the breaking changes between the latest
+// release version tag, such as v1.2.3, reachable from HEAD and the working
+// directory.
 //
 // By providing one argument treeish: it reports the breaking changes between
 // treeish and the working directory.
@@ -24,8 +118,60 @@
 // By providing two arguments treeish1 and treeish2: it reports the breaking
 // changes between treeish1 and treeish2.
 //
+// Each change is printed on its own line: the import path and name of the
+// object, followed by an explanation of what changed. Warnings and
+// serialization changes are marked as such.
+//
+// With the -all flag, compatible changes such as added names, fields and
+// methods are also reported, marked as compatible.
+//
+// The -format flag selects the output format, text by default. With
+// -format=json, a single JSON object is printed, with the following schema:
+//
+//	{
+//	  "changes": [
+//	    {
+//	      "package":   string,   // import path of the package
+//	      "name":      string,   // name of the object; empty for a whole package
+//	      "kind":      string,   // rule that reported the change, e.g. "FieldRemoved"
+//	      "path":      string,   // location within the object, e.g. "params[1]"; may be empty
+//	      "severity":  string,   // "breaking", "warning", "serialization" or "compatible"
+//	      "message":   string,   // explanation of the change
+//	      "platforms": [string], // platforms the change is limited to, e.g. "linux/amd64"; absent if all
+//	      "old":       object,   // object before the change; absent if added
+//	      "new":       object    // object after the change; absent if removed
+//	    }
+//	  ]
+//	}
+//
+// where old and new objects are:
+//
+//	{
+//	  "decl": string, // declaration of the object as it appears in code
+//	  "pos": {
+//	    "filename": string, // slash-separated, relative to the current directory if within it
+//	    "line":     number, // starting at 1
+//	    "column":   number  // starting at 1, in bytes
+//	  }
+//	}
+//
+// Fields are only ever added to this schema.
+//
+// With -format=sarif, a SARIF 2.1.0 log is printed, with one rule per kind
+// of change and one result per change, located at the new declaration of
+// the object or, if it was removed, at the old one.
+//
+// With the -semver flag, gobreaking instead prints the semantic version to
+// release next, following the latest release version tag reachable from
+// treeish1: a major version if any change is breaking, a minor version if
+// any change is compatible, such as an addition, and a patch version
+// otherwise. Before v1.0.0, breaking changes only call for a minor version,
+// and other changes for a patch version. If the new major version requires
+// a different module path, ending in /vN, the new path is noted on standard
+// error. Pre-release tags are ignored.
+//
 // The exit code of gobreaking is 2 for erroneous invocation,
-// 1 if a breaking change was reported, and 0 otherwise.
+// 1 if a breaking change was reported without -semver, and 0 otherwise.
 package main
 
 import (
@@ -33,11 +179,39 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/sprt/breaking"
 	"github.com/sprt/breaking/cmd/gobreaking/internal/git"
+	"github.com/sprt/breaking/internal/load"
 )
 
+var (
+	all          = flag.Bool("all", false, "also report compatible changes, such as additions")
+	allowErrors  = flag.Bool("allow-type-errors", false, "compare packages despite type errors, printing them as warnings")
+	constToVar   = flag.String("const-to-var", "breaking", "`severity` of constants becoming variables: breaking, warning or compatible")
+	format       = flag.String("format", "text", "output `format`: text, json or sarif")
+	platforms    = flag.String("platforms", "", "comma-separated `GOOS/GOARCH` pairs to compare the packages for")
+	semver       = flag.Bool("semver", false, "print the recommended next version instead of the changes")
+	sourceCompat = flag.Bool("source-compatible", false, "allow changes to functions that keep calls compiling")
+	strictConsts = flag.Bool("strict-consts", false, "report changes to constant values as breaking")
+	unkeyed      = flag.Bool("unkeyed-literals", false, "report changes that only break unkeyed struct literals as warnings")
+	structTags   = flag.String("struct-tags", "json,xml,yaml,protobuf", "comma-separated `keys` of struct tags to compare")
+	tags         = flag.String("tags", "", "comma-separated build `tags` to satisfy")
+)
+
+var formats = map[string]func(io.Writer, []*breaking.ObjectDiff) error{
+	"text":  writeText,
+	"json":  writeJSON,
+	"sarif": writeSARIF,
+}
+
+var severities = map[string]breaking.Severity{
+	"breaking":   breaking.Breaking,
+	"warning":    breaking.Warning,
+	"compatible": breaking.Compatible,
+}
+
 func init() {
 	flag.Usage = usage
 }
@@ -45,61 +219,161 @@ func init() {
 func main() {
 	flag.Parse()
 
-	var a, b interface{}
+	write, ok := formats[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+	constToVarSeverity, ok := severities[*constToVar]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown severity %q\n", *constToVar)
+		os.Exit(2)
+	}
+
+	var a, b breaking.Source
+	var baseline string // treeish of the old tree
 	switch flag.NArg() {
-	case 1:
-		head, err := treeFiles(flag.Arg(0))
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(2)
+	case 0, 1:
+		if flag.NArg() == 1 {
+			baseline = flag.Arg(0)
+		} else {
+			tag, err := git.LatestVersionTag("HEAD")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			if tag == "" {
+				fmt.Fprintln(os.Stderr, "no version tag found; provide a treeish")
+				os.Exit(2)
+			}
+			baseline = tag
 		}
 		wd, err := os.Getwd()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(2)
 		}
-		a, b = head, wd
+		a, b = breaking.GitTree(".", baseline), breaking.Dir(wd)
 	case 2:
-		x, err := treeFiles(flag.Arg(0))
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(2)
-		}
-		y, err := treeFiles(flag.Arg(1))
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(2)
-		}
-		a, b = x, y
+		baseline = flag.Arg(0)
+		a, b = breaking.GitTree(".", baseline), breaking.GitTree(".", flag.Arg(1))
 	default:
 		fmt.Fprintln(os.Stderr, "wrong number of arguments")
 		os.Exit(2)
 	}
 
-	diffs, err := breaking.ComparePackages(a, b)
+	var opts []breaking.Option
+	if *all || *semver {
+		opts = append(opts, breaking.IncludeCompatible())
+	}
+	if *sourceCompat {
+		opts = append(opts, breaking.SourceCompatible())
+	}
+	if *strictConsts {
+		opts = append(opts, breaking.WithSeverity(breaking.ConstValueChanged, breaking.Breaking))
+	}
+	opts = append(opts, breaking.WithSeverity(breaking.ConstBecameVar, constToVarSeverity))
+	if *unkeyed {
+		opts = append(opts, breaking.SeparateUnkeyedLiterals())
+	}
+	var keys []string
+	if *structTags != "" {
+		keys = strings.Split(*structTags, ",")
+	}
+	opts = append(opts, breaking.StructTags(keys...))
+	if *tags != "" {
+		opts = append(opts, breaking.BuildTags(strings.Split(*tags, ",")...))
+	}
+	if *platforms != "" {
+		opts = append(opts, breaking.Platforms(strings.Split(*platforms, ",")...))
+	}
+
+	if *allowErrors {
+		opts = append(opts, breaking.AllowTypeErrors(warnTypeErrors))
+	}
+
+	diffs, err := breaking.CompareTrees(a, b, opts...)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
 
-	for _, d := range diffs {
-		fmt.Println(d.Name())
+	if *semver {
+		if err := writeVersion(os.Stdout, baseline, modulePath(a), diffs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
 	}
 
-	if len(diffs) != 0 {
-		os.Exit(1)
+	if err := write(os.Stdout, diffs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, d := range diffs {
+		if d.Severity() == breaking.Breaking {
+			os.Exit(1)
+		}
 	}
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s treeish1 [treeish2]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [treeish1 [treeish2]]\n", os.Args[0])
 	flag.PrintDefaults()
 }
 
-func treeFiles(treeish string) (map[string]io.Reader, error) {
-	tree, err := git.LsTree(treeish)
+// warnTypeErrors prints the type errors of err on standard error,
+// one per line.
+func warnTypeErrors(err *breaking.CheckError) {
+	version := "new"
+	if err.Old {
+		version = "old"
+	}
+	for _, e := range err.Errors {
+		fmt.Fprintf(os.Stderr, "warning: %s version of %s: %v\n", version, err.Path, e)
+	}
+}
+
+// writeVersion writes the version to release after the latest version tag
+// reachable from baseline, given diffs. If the release requires a new module
+// path, it also notes it on standard error.
+func writeVersion(w io.Writer, baseline, modPath string, diffs []*breaking.ObjectDiff) error {
+	current, err := git.LatestVersionTag(baseline)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return fmt.Errorf("no version tag reachable from %s", baseline)
+	}
+	next, err := breaking.NextVersion(current, modPath, diffs)
+	if err != nil {
+		return err
+	}
+	if modPath != "" {
+		if p, err := breaking.VersionModulePath(modPath, next); err == nil && p != modPath {
+			fmt.Fprintf(os.Stderr, "note: the module path must change to %s\n", p)
+		}
+	}
+	_, err = fmt.Fprintln(w, next)
+	return err
+}
+
+// modulePath returns the path of the module at the root of src,
+// or the empty string if there is no go.mod file.
+func modulePath(src breaking.Source) string {
+	r, err := src.Open("go.mod")
+	if err != nil {
+		return ""
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return ""
+	}
+	mf, err := load.ParseModFile(data)
 	if err != nil {
-		return nil, err
+		return ""
 	}
-	return tree.GoFiles()
+	return mf.Module
 }
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/sprt/breaking"
+)
+
+// sarifRules describes each kind of change, following the list in the
+// package comment. Keep them in sync. Levels are those of the default
+// severities.
+var sarifRules = []struct {
+	kind  breaking.ChangeKind
+	level string
+	desc  string
+}{
+	{breaking.Removed, "error", "Removing a name (constant, type, variable, function)."},
+	{breaking.KindChanged, "error", "Changing the kind of a name."},
+	{breaking.ConstBecameVar, "error", "Changing a constant into a variable."},
+	{breaking.ConstBecameFunc, "error", "Changing a constant into a function."},
+	{breaking.ConstBecameType, "error", "Changing a constant into a type."},
+	{breaking.VarBecameConst, "error", "Changing a variable into a constant."},
+	{breaking.VarBecameFunc, "error", "Changing a variable into a function."},
+	{breaking.VarBecameType, "error", "Changing a variable into a type."},
+	{breaking.FuncBecameConst, "error", "Changing a function into a constant."},
+	{breaking.FuncBecameType, "error", "Changing a function into a type."},
+	{breaking.TypeBecameConst, "error", "Changing a type into a constant."},
+	{breaking.TypeBecameVar, "error", "Changing a type into a variable."},
+	{breaking.TypeBecameFunc, "error", "Changing a type into a function."},
+	{breaking.TypeChanged, "error", "Changing the type of a variable or constant, or the underlying type of a type."},
+	{breaking.MethodAdded, "error", "Adding a method to an interface."},
+	{breaking.MethodRemoved, "error", "Removing a method from an interface, or an exported method from a type."},
+	{breaking.ParamAdded, "error", "Adding a parameter to a function or method."},
+	{breaking.ParamRemoved, "error", "Removing a parameter from a function or method."},
+	{breaking.ParamTypeChanged, "error", "Changing the type of a parameter of a function or method."},
+	{breaking.ResultAdded, "error", "Adding a result to a function or method."},
+	{breaking.ResultRemoved, "error", "Removing a result from a function or method."},
+	{breaking.ResultTypeChanged, "error", "Changing the type of a result of a function or method."},
+	{breaking.VariadicChanged, "error", "Making a function variadic, or no longer variadic."},
+	{breaking.FieldTypeChanged, "error", "Changing the type of an exported struct field."},
+	{breaking.FieldRemoved, "error", "Removing an exported field from a struct."},
+	{breaking.UnexportedFieldAdded, "error", "Adding an unexported field to a struct containing only exported fields."},
+	{breaking.FieldRepositioned, "error", "Repositioning a field in a struct containing only exported fields."},
+	{breaking.MethodReceiverChanged, "error", "Moving an exported method from a value receiver to a pointer receiver."},
+	{breaking.ConstBecameTyped, "error", "Changing an untyped constant into a typed constant."},
+	{breaking.ConstValueChanged, "warning", "Changing the value of a constant."},
+	{breaking.TypeParamAdded, "error", "Adding a type parameter to a generic function or type."},
+	{breaking.TypeParamRemoved, "error", "Removing a type parameter from a generic function or type."},
+	{breaking.ConstraintTightened, "error", "Tightening the constraint of a type parameter, or the type set of a constraint interface."},
+	{breaking.PackageRemoved, "error", "Removing a package."},
+	{breaking.DefinedBecameAlias, "error", "Turning a defined type into an alias for a type that is not a defined type."},
+	{breaking.AliasBecameDefined, "error", "Turning an alias into a defined type."},
+	{breaking.SelectorAmbiguous, "error", "Making a promoted field or method ambiguous by embedding another field."},
+	{breaking.ComparabilityLost, "error", "Making a type incomparable, e.g. by adding a slice, map or func field to a struct."},
+	{breaking.UnkeyedLiteralBroken, "warning", "Adding or moving a field of a struct with only exported fields, with -unkeyed-literals."},
+	{breaking.ChanDirChanged, "error", "Changing the direction of a channel parameter or result, unless widened with -source-compatible."},
+	{breaking.TagChanged, "warning", "Changing the struct tag of an exported field for an encoding."},
+	{breaking.Added, "note", "Adding a name."},
+	{breaking.FieldAdded, "note", "Adding an exported field to a struct."},
+	{breaking.MethodAddedToType, "note", "Adding an exported method to a type."},
+	{breaking.ConstraintLoosened, "note", "Loosening the constraint of a type parameter, or the type set of a constraint interface."},
+	{breaking.PackageAdded, "note", "Adding a package."},
+	{breaking.VariadicParamAdded, "note", "Adding a final variadic parameter to a function, with -source-compatible."},
+	{breaking.ParamTypeWidened, "note", "Changing a parameter to an interface that the old type implements, with -source-compatible."},
+	{breaking.ResultTypeNarrowed, "note", "Changing an interface result to an interface with all of its methods, with -source-compatible."},
+	{breaking.FuncBecameVar, "note", "Changing a function into a variable of the same type."},
+}
+
+// The types below are the subset of the SARIF 2.1.0 format used by gobreaking.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"` // nil if the kind has no rule
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func writeSARIF(w io.Writer, diffs []*breaking.ObjectDiff) error {
+	driver := sarifDriver{
+		Name:           "gobreaking",
+		InformationURI: "https://godoc.org/github.com/sprt/breaking/cmd/gobreaking",
+	}
+	ruleIndex := make(map[breaking.ChangeKind]int)
+	for i, r := range sarifRules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.kind.String(),
+			ShortDescription:     sarifMessage{r.desc},
+			DefaultConfiguration: sarifConfiguration{r.level},
+		})
+		ruleIndex[r.kind] = i
+	}
+
+	results := make([]sarifResult, 0, len(diffs))
+	for _, d := range diffs {
+		r := sarifResult{
+			RuleID:    d.Kind().String(),
+			Level:     sarifLevel(d.Severity()),
+			Message:   sarifMessage{sarifText(d)},
+			Locations: []sarifLocation{sarifLocate(d)},
+		}
+		if i, ok := ruleIndex[d.Kind()]; ok {
+			r.RuleIndex = &i
+		}
+		results = append(results, r)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{driver},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifLevel(s breaking.Severity) string {
+	switch s {
+	case breaking.Breaking:
+		return "error"
+	case breaking.Warning, breaking.Serialization:
+		return "warning"
+	}
+	return "note"
+}
+
+// sarifText returns the message of the result for d: the explanation of
+// the change followed by a diff of the declarations.
+func sarifText(d *breaking.ObjectDiff) string {
+	var b strings.Builder
+	b.WriteString(qualifiedName(d) + ": " + d.Reason())
+	if p := d.Platforms(); p != nil {
+		b.WriteString(" [" + strings.Join(p, ", ") + " only]")
+	}
+	if d.Old() != nil || d.New() != nil {
+		b.WriteString("\n")
+		writeDecl(&b, "-", d.Old())
+		writeDecl(&b, "+", d.New())
+	}
+	return b.String()
+}
+
+// writeDecl writes the declaration of obj, if not nil,
+// with every line prefixed by prefix.
+func writeDecl(b *strings.Builder, prefix string, obj *breaking.Object) {
+	if obj == nil {
+		return
+	}
+	for _, line := range strings.Split(obj.String(), "\n") {
+		b.WriteString("\n" + prefix + " " + line)
+	}
+}
+
+// sarifLocate returns the location of the result for d: the new object,
+// or the old one if it was removed.
+func sarifLocate(d *breaking.ObjectDiff) sarifLocation {
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{
+			FullyQualifiedName: qualifiedName(d),
+			Kind:               "member",
+		}},
+	}
+	if d.Name() == "" {
+		loc.LogicalLocations[0].Kind = "package"
+	}
+
+	obj := d.New()
+	if obj == nil {
+		obj = d.Old()
+	}
+	if obj == nil {
+		return loc
+	}
+	pos := obj.Fpos()
+	artifact := sarifArtifactLocation{URI: relFilename(pos.Filename)}
+	if !filepath.IsAbs(filepath.FromSlash(artifact.URI)) {
+		artifact.URIBaseID = "%SRCROOT%"
+	} else {
+		artifact.URI = "file://" + artifact.URI
+	}
+	loc.PhysicalLocation = &sarifPhysicalLocation{
+		ArtifactLocation: artifact,
+		Region: sarifRegion{
+			StartLine:   pos.Line,
+			StartColumn: pos.Column,
+		},
+	}
+	return loc
+}
@@ -0,0 +1,5 @@
+package p
+
+func Added() {}
+
+func F(s string) {}
@@ -0,0 +1,113 @@
+package breaking
+
+import (
+	"go/build"
+	"go/types"
+)
+
+// A Config configures the comparison of packages. It is an alternative to
+// the options of ComparePackages and CompareTrees. The zero value compares
+// packages as these functions do without options.
+type Config struct {
+	// Strictness adjusts which changes are reported as breaking.
+	Strictness Strictness
+
+	// Severity overrides the severity of the changes of the given kinds,
+	// as WithSeverity does.
+	Severity map[ChangeKind]Severity
+
+	// Disabled lists the kinds of changes not to report.
+	Disabled map[ChangeKind]bool
+
+	// IncludeCompatible reports compatible changes, such as additions,
+	// as the IncludeCompatible option does.
+	IncludeCompatible bool
+
+	// LenientNamedTypes compares named types by structure,
+	// as the LenientNamedTypes option does.
+	LenientNamedTypes bool
+
+	// SourceCompatible only reports changes to functions that break calls
+	// to them, as the SourceCompatible option does.
+	SourceCompatible bool
+
+	// SeparateUnkeyedLiterals reports the changes that only break unkeyed
+	// struct literals as warnings, as the SeparateUnkeyedLiterals option does.
+	SeparateUnkeyedLiterals bool
+
+	// StructTags are the keys of the struct tags to compare. If nil, those
+	// of common encodings are compared, as described for the StructTags
+	// option; if empty but not nil, struct tags are ignored.
+	StructTags []string
+
+	// Context selects the files of packages by their build constraints.
+	// If nil, build.Default is used.
+	Context *build.Context
+
+	// Platforms, if not empty, compares packages for each of the given
+	// platforms, overriding the GOOS and GOARCH of Context, as the
+	// Platforms option does.
+	Platforms []string
+
+	// Importer, if not nil, resolves the imports of both versions of the
+	// packages, instead of the importers described for ComparePackages.
+	Importer types.Importer
+
+	// TypeErrors, if not nil, is called with the type errors found in
+	// a version of a package, which is then compared with the partial
+	// type information found, as with AllowTypeErrors. Otherwise,
+	// type errors fail the comparison.
+	TypeErrors func(*CheckError)
+}
+
+// A Strictness adjusts which changes are reported as breaking.
+type Strictness int
+
+const (
+	// Normal reports changes with the severities described for each
+	// kind of change.
+	Normal Strictness = iota
+
+	// Strict reports every change that is not compatible as breaking,
+	// including warnings and serialization changes.
+	Strict
+
+	// Lenient compares named types by structure, and functions by whether
+	// calls to them still compile, as LenientNamedTypes and SourceCompatible
+	// do.
+	Lenient
+)
+
+// ComparePackages returns the changes introduced by package b relative
+// to package a, as the ComparePackages function does.
+func (c *Config) ComparePackages(a, b Source) ([]*ObjectDiff, error) {
+	return compareSourcePackages(a, b, c.options())
+}
+
+// CompareTrees returns the changes introduced by tree b relative to tree a,
+// as the CompareTrees function does.
+func (c *Config) CompareTrees(a, b Source) ([]*ObjectDiff, error) {
+	return compareSourceTrees(a, b, c.options())
+}
+
+func (c *Config) options() *options {
+	o := newOptions(nil)
+	for kind, s := range c.Severity {
+		o.severity[kind] = s
+	}
+	o.disabled = c.Disabled
+	o.strict = c.Strictness == Strict
+	o.compatible = c.IncludeCompatible
+	o.cmp.LenientNamed = c.LenientNamedTypes || c.Strictness == Lenient
+	o.cmp.SourceCompatible = c.SourceCompatible || c.Strictness == Lenient
+	o.cmp.UnkeyedLiterals = c.SeparateUnkeyedLiterals
+	if c.StructTags != nil {
+		o.cmp.TagKeys = c.StructTags
+	}
+	o.ctxt = c.Context
+	o.platforms = c.Platforms
+	o.importer = c.Importer
+	o.allowTypeErrors = c.TypeErrors != nil
+	o.onTypeError = c.TypeErrors
+	return o
+}
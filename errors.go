@@ -0,0 +1,35 @@
+package breaking
+
+import "fmt"
+
+// A CheckError reports the errors found while parsing and type-checking
+// a version of a package.
+type CheckError struct {
+	// Old is set if the errors are in the old version of the package,
+	// a as passed to ComparePackages or CompareTrees, rather than the new one.
+	Old bool
+
+	// Path is the import path of the package, or its directory.
+	Path string
+
+	// Errors are the errors found, each with a position:
+	// a *scanner.Error for a syntax error, or a types.Error.
+	Errors []error
+}
+
+func (e *CheckError) Error() string {
+	version := "new"
+	if e.Old {
+		version = "old"
+	}
+	msg := fmt.Sprintf("%s version of %s: %v", version, e.Path, e.Errors[0])
+	if n := len(e.Errors) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more errors)", n)
+	}
+	return msg
+}
+
+// Unwrap returns e.Errors.
+func (e *CheckError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,310 @@
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A Tree gives access to the files of a source tree, such as a directory
+// or a revision of a repository. Names are slash-separated and relative
+// to the root of the tree.
+type Tree interface {
+	// ReadDir returns the names of the files, excluding directories, in dir.
+	ReadDir(dir string) ([]string, error)
+
+	// ReadFile returns the contents of the named file.
+	ReadFile(name string) ([]byte, error)
+}
+
+// Dir is a Tree rooted at a directory of the local file system.
+type Dir string
+
+func (d Dir) ReadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(string(d), filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d Dir) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// A subTree is the part of a Tree below a directory.
+type subTree struct {
+	t   Tree
+	dir string
+}
+
+func (t subTree) ReadDir(dir string) ([]string, error) {
+	return t.t.ReadDir(path.Join(t.dir, dir))
+}
+
+func (t subTree) ReadFile(name string) ([]byte, error) {
+	return t.t.ReadFile(path.Join(t.dir, name))
+}
+
+// An Importer imports packages for type-checking the packages of a module.
+//
+// Packages of the module itself are read from its tree. Dependencies are
+// read from the vendor directory of the tree if there is one, or else from
+// the module cache at the versions required by go.mod, subject to its
+// replace directives. They must be listed in go.sum if the tree has one.
+// The standard library is imported with importer.Default.
+// The network is never accessed.
+//
+// Dependencies are type-checked from source, ignoring type errors,
+// so that an incomplete dependency still provides partial type information.
+// Their files are selected by the build context of the importer.
+type Importer struct {
+	tree     Tree
+	ctxt     *build.Context
+	mod      *ModFile
+	sums     map[Version]bool // nil if there is no go.sum
+	vendor   bool
+	modCache string
+	std      types.Importer
+	fset     *token.FileSet
+	pkgs     map[string]*types.Package
+}
+
+// NewImporter returns an importer for the module at the root of tree,
+// selecting files with ctxt, or build.Default if nil.
+func NewImporter(tree Tree, ctxt *build.Context) (*Importer, error) {
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	data, err := tree.ReadFile("go.mod")
+	if err != nil {
+		return nil, err
+	}
+	mod, err := ParseModFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &Importer{
+		tree:     tree,
+		ctxt:     ctxt,
+		mod:      mod,
+		modCache: ModCache(),
+		std:      StdImporter(ctxt),
+		fset:     token.NewFileSet(),
+		pkgs:     make(map[string]*types.Package),
+	}
+	if data, err := tree.ReadFile("go.sum"); err == nil {
+		imp.sums = ParseSumFile(data)
+	}
+	if _, err := tree.ReadFile("vendor/modules.txt"); err == nil {
+		imp.vendor = true
+	}
+	return imp, nil
+}
+
+// Import implements types.Importer.
+func (imp *Importer) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	t, dir, err := imp.locate(importPath)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return imp.std.Import(importPath)
+	}
+	pkg, err := imp.check(importPath, t, dir)
+	if err != nil {
+		return nil, err
+	}
+	imp.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+// locate returns the tree and directory containing the package with the
+// given import path, or a nil tree for a package of the standard library.
+func (imp *Importer) locate(importPath string) (Tree, string, error) {
+	if rel, ok := within(importPath, imp.mod.Module); ok {
+		return imp.tree, rel, nil
+	}
+	if isStd(importPath) {
+		return nil, "", nil
+	}
+	if imp.vendor {
+		return imp.tree, "vendor/" + importPath, nil
+	}
+
+	var m Version
+	for _, r := range imp.mod.Require {
+		if _, ok := within(importPath, r.Path); ok && len(r.Path) > len(m.Path) {
+			m = r
+		}
+	}
+	if m.Path == "" {
+		return nil, "", fmt.Errorf("no required module provides package %s", importPath)
+	}
+	rel, _ := within(importPath, m.Path)
+
+	for _, r := range imp.mod.Replace {
+		if r.Old.Path != m.Path || r.Old.Version != "" && r.Old.Version != m.Version {
+			continue
+		}
+		if isLocalPath(r.New.Path) {
+			return imp.localReplacement(r.New.Path), rel, nil
+		}
+		m = r.New
+		break
+	}
+
+	if imp.sums != nil && !imp.sums[m] {
+		return nil, "", fmt.Errorf("missing go.sum entry for module providing package %s", importPath)
+	}
+	dir := filepath.Join(imp.modCache, escape(m.Path)+"@"+escape(m.Version))
+	if _, err := os.Stat(dir); err != nil {
+		return nil, "", fmt.Errorf("%s@%s is not in the module cache", m.Path, m.Version)
+	}
+	return Dir(dir), rel, nil
+}
+
+// localReplacement returns the tree of a module replaced by a directory,
+// relative to the root of the main module.
+func (imp *Importer) localReplacement(dir string) Tree {
+	if filepath.IsAbs(dir) {
+		return Dir(dir)
+	}
+	if d, ok := imp.tree.(Dir); ok {
+		return Dir(filepath.Join(string(d), filepath.FromSlash(dir)))
+	}
+	return subTree{imp.tree, path.Clean(dir)}
+}
+
+// check parses and type-checks the package in dir.
+func (imp *Importer) check(importPath string, t Tree, dir string) (*types.Package, error) {
+	return checkDir(BuildContext(imp.ctxt, t), imp.fset, imp, t, dir, importPath)
+}
+
+// checkDir parses and type-checks the package in dir of t with the given
+// import path, from the files matching ctxt, ignoring type errors.
+func checkDir(ctxt *build.Context, fset *token.FileSet, imp types.Importer, t Tree, dir, importPath string) (*types.Package, error) {
+	names, err := t.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find package %s: %v", importPath, err)
+	}
+
+	var files []*ast.File
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := ctxt.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		filename := path.Join(dir, name)
+		src, err := t.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, filename, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files for package %s", importPath)
+	}
+
+	conf := &types.Config{
+		Error:            func(error) {},
+		FakeImportC:      true,
+		IgnoreFuncBodies: true,
+		Importer:         imp,
+	}
+	pkg, _ := conf.Check(importPath, fset, files, nil)
+	return pkg, nil
+}
+
+// BuildContext returns a copy of ctxt that reads files from t,
+// for matching files against build constraints. Cgo is disabled.
+func BuildContext(ctxt *build.Context, t Tree) *build.Context {
+	c := *ctxt
+	c.CgoEnabled = false
+	c.JoinPath = path.Join
+	c.OpenFile = func(name string) (io.ReadCloser, error) {
+		data, err := t.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return &c
+}
+
+// ModCache returns the module cache directory.
+func ModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if out, err := exec.Command("go", "env", "GOMODCACHE").Output(); err == nil {
+		if dir := strings.TrimSpace(string(out)); dir != "" {
+			return dir
+		}
+	}
+	return filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+}
+
+// within reports whether importPath is prefix or below it,
+// and returns the slash-separated directory of importPath relative to prefix.
+func within(importPath, prefix string) (string, bool) {
+	if importPath == prefix {
+		return ".", true
+	}
+	if strings.HasPrefix(importPath, prefix+"/") {
+		return importPath[len(prefix)+1:], true
+	}
+	return "", false
+}
+
+// isStd reports whether importPath is a package of the standard library,
+// the first element of which has no dot.
+func isStd(importPath string) bool {
+	elem, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(elem, ".")
+}
+
+// escape escapes a module path or version as in the module cache,
+// replacing every upper-case letter with an exclamation mark followed
+// by the letter's lower-case equivalent.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
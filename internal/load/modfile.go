@@ -0,0 +1,149 @@
+// Package load resolves the imports of the packages of a module at some
+// revision, using the dependency versions pinned by its go.mod file.
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A ModFile holds the directives of a go.mod file that matter for
+// resolving imports.
+type ModFile struct {
+	Module  string
+	Require []Version
+	Replace []Replacement
+}
+
+// A Version identifies a version of a module.
+type Version struct {
+	Path    string
+	Version string // empty for a local directory or any version
+}
+
+// A Replacement is a replace directive. New.Version is empty if New.Path
+// is a directory.
+type Replacement struct {
+	Old, New Version
+}
+
+// ParseModFile parses the contents of a go.mod file.
+func ParseModFile(data []byte) (*ModFile, error) {
+	mf := &ModFile{}
+	var block string // verb of the current ( ... ) block
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		args := make([]string, len(fields))
+		for i, f := range fields {
+			s, err := unquote(f)
+			if err != nil {
+				return nil, fmt.Errorf("go.mod:%d: %v", lineno, err)
+			}
+			args[i] = s
+		}
+
+		switch verb {
+		case "module":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("go.mod:%d: usage: module module/path", lineno)
+			}
+			mf.Module = args[0]
+		case "require":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("go.mod:%d: usage: require module/path v1.2.3", lineno)
+			}
+			mf.Require = append(mf.Require, Version{args[0], args[1]})
+		case "replace":
+			r, ok := parseReplacement(args)
+			if !ok {
+				return nil, fmt.Errorf("go.mod:%d: usage: replace module/path [v1.2.3] => other/module v1.4.5 or ./dir", lineno)
+			}
+			mf.Replace = append(mf.Replace, r)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if mf.Module == "" {
+		return nil, fmt.Errorf("go.mod: no module directive")
+	}
+	return mf, nil
+}
+
+func parseReplacement(args []string) (Replacement, bool) {
+	var r Replacement
+	arrow := -1
+	for i, a := range args {
+		if a == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 {
+		return r, false
+	}
+	from, to := args[:arrow], args[arrow+1:]
+	if len(from) > 2 || len(to) < 1 || len(to) > 2 {
+		return r, false
+	}
+	r.Old.Path = from[0]
+	if len(from) == 2 {
+		r.Old.Version = from[1]
+	}
+	r.New.Path = to[0]
+	if len(to) == 2 {
+		r.New.Version = to[1]
+	}
+	return r, true
+}
+
+func unquote(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
+
+// ParseSumFile returns the module versions listed in the contents of a
+// go.sum file, keyed by path and version.
+func ParseSumFile(data []byte) map[Version]bool {
+	sums := make(map[Version]bool)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		sums[Version{fields[0], strings.TrimSuffix(fields[1], "/go.mod")}] = true
+	}
+	return sums
+}
+
+// isLocalPath reports whether the replacement path p is a directory.
+func isLocalPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") ||
+		strings.HasPrefix(p, "/") || p == "." || p == ".."
+}
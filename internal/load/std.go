@@ -0,0 +1,57 @@
+package load
+
+import (
+	"go/build"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+// StdImporter returns an importer for the standard library as built
+// for ctxt. If ctxt targets the same platform as build.Default, it is
+// importer.Default. Otherwise, packages are type-checked from source in
+// GOROOT, which is slower but exposes the API of the target platform,
+// such as that of package syscall.
+func StdImporter(ctxt *build.Context) types.Importer {
+	if ctxt.GOOS == build.Default.GOOS && ctxt.GOARCH == build.Default.GOARCH {
+		return importer.Default()
+	}
+	src := Dir(filepath.Join(ctxt.GOROOT, "src"))
+	return &stdImporter{
+		ctxt: BuildContext(ctxt, src),
+		src:  src,
+		fset: token.NewFileSet(),
+		pkgs: make(map[string]*types.Package),
+	}
+}
+
+// A stdImporter type-checks the standard library from source.
+type stdImporter struct {
+	ctxt *build.Context
+	src  Tree
+	fset *token.FileSet
+	pkgs map[string]*types.Package
+}
+
+// Import implements types.Importer.
+func (imp *stdImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	// The dependencies of the standard library are vendored.
+	dir := importPath
+	if !isStd(importPath) {
+		dir = "vendor/" + importPath
+	}
+	pkg, err := checkDir(imp.ctxt, imp.fset, imp, imp.src, dir, importPath)
+	if err != nil {
+		return nil, err
+	}
+	imp.pkgs[importPath] = pkg
+	return pkg, nil
+}
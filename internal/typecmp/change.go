@@ -0,0 +1,137 @@
+package typecmp
+
+import "strconv"
+
+// A Kind identifies the rule that reported a difference between two objects.
+type Kind int
+
+const (
+	Removed Kind = iota + 1
+	KindChanged
+	TypeChanged
+	ParamAdded
+	ParamRemoved
+	ParamTypeChanged
+	ResultAdded
+	ResultRemoved
+	ResultTypeChanged
+	VariadicChanged
+	FieldRemoved
+	FieldTypeChanged
+	FieldRepositioned
+	UnexportedFieldAdded
+	MethodAdded
+	MethodRemoved
+	MethodReceiverChanged
+	ConstValueChanged
+	ConstBecameTyped
+	TypeParamAdded
+	TypeParamRemoved
+	ConstraintTightened
+	Added
+	FieldAdded
+	MethodAddedToType
+	ConstraintLoosened
+	PackageRemoved
+	PackageAdded
+	DefinedBecameAlias
+	AliasBecameDefined
+	VariadicParamAdded
+	ParamTypeWidened
+	ResultTypeNarrowed
+	SelectorAmbiguous
+	TagChanged
+	ComparabilityLost
+	UnkeyedLiteralBroken
+	ChanDirChanged
+	ConstBecameVar
+	ConstBecameFunc
+	ConstBecameType
+	VarBecameConst
+	VarBecameFunc
+	VarBecameType
+	FuncBecameConst
+	FuncBecameVar
+	FuncBecameType
+	TypeBecameConst
+	TypeBecameVar
+	TypeBecameFunc
+)
+
+var kindNames = [...]string{
+	Removed:               "Removed",
+	KindChanged:           "KindChanged",
+	TypeChanged:           "TypeChanged",
+	ParamAdded:            "ParamAdded",
+	ParamRemoved:          "ParamRemoved",
+	ParamTypeChanged:      "ParamTypeChanged",
+	ResultAdded:           "ResultAdded",
+	ResultRemoved:         "ResultRemoved",
+	ResultTypeChanged:     "ResultTypeChanged",
+	VariadicChanged:       "VariadicChanged",
+	FieldRemoved:          "FieldRemoved",
+	FieldTypeChanged:      "FieldTypeChanged",
+	FieldRepositioned:     "FieldRepositioned",
+	UnexportedFieldAdded:  "UnexportedFieldAdded",
+	MethodAdded:           "MethodAdded",
+	MethodRemoved:         "MethodRemoved",
+	MethodReceiverChanged: "MethodReceiverChanged",
+	ConstValueChanged:     "ConstValueChanged",
+	ConstBecameTyped:      "ConstBecameTyped",
+	TypeParamAdded:        "TypeParamAdded",
+	TypeParamRemoved:      "TypeParamRemoved",
+	ConstraintTightened:   "ConstraintTightened",
+	Added:                 "Added",
+	FieldAdded:            "FieldAdded",
+	MethodAddedToType:     "MethodAddedToType",
+	ConstraintLoosened:    "ConstraintLoosened",
+	PackageRemoved:        "PackageRemoved",
+	PackageAdded:          "PackageAdded",
+	DefinedBecameAlias:    "DefinedBecameAlias",
+	AliasBecameDefined:    "AliasBecameDefined",
+	VariadicParamAdded:    "VariadicParamAdded",
+	ParamTypeWidened:      "ParamTypeWidened",
+	ResultTypeNarrowed:    "ResultTypeNarrowed",
+	SelectorAmbiguous:     "SelectorAmbiguous",
+	TagChanged:            "TagChanged",
+	ComparabilityLost:     "ComparabilityLost",
+	UnkeyedLiteralBroken:  "UnkeyedLiteralBroken",
+	ChanDirChanged:        "ChanDirChanged",
+	ConstBecameVar:        "ConstBecameVar",
+	ConstBecameFunc:       "ConstBecameFunc",
+	ConstBecameType:       "ConstBecameType",
+	VarBecameConst:        "VarBecameConst",
+	VarBecameFunc:         "VarBecameFunc",
+	VarBecameType:         "VarBecameType",
+	FuncBecameConst:       "FuncBecameConst",
+	FuncBecameVar:         "FuncBecameVar",
+	FuncBecameType:        "FuncBecameType",
+	TypeBecameConst:       "TypeBecameConst",
+	TypeBecameVar:         "TypeBecameVar",
+	TypeBecameFunc:        "TypeBecameFunc",
+}
+
+func (k Kind) String() string {
+	if k > 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A Change describes a single difference between two objects.
+type Change struct {
+	Kind Kind
+
+	// Compatible is set if the change cannot break users of the object,
+	// e.g. a new field or method.
+	Compatible bool
+
+	// Path locates the change within the object, e.g. "Foo" for a field
+	// or method, "params[1]" for the second parameter, or
+	// "Close.results[0]" for the first result of method Close.
+	// It is empty if the change applies to the object as a whole.
+	Path string
+
+	// Msg explains the change in plain English.
+	Msg string
+}
@@ -0,0 +1,842 @@
+package typecmp
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// Options configure the comparison of two objects.
+// The zero value compares them strictly.
+type Options struct {
+	// LenientNamed compares named types by their underlying types rather
+	// than by qualified name, so that a type can be replaced by another
+	// with the same structure, or by its underlying type.
+	LenientNamed bool
+
+	// SourceCompatible allows changes to functions and methods of
+	// non-interface types that keep calls to them compiling, although they
+	// break other uses, such as assigning a function to a variable or
+	// satisfying an interface with a method: adding a final variadic
+	// parameter, changing a parameter of a non-basic type to an interface
+	// that the type implements, changing an interface result to an interface
+	// that has all of its methods, and widening the direction of a channel
+	// parameter or result. They are reported as compatible changes of kinds
+	// VariadicParamAdded, ParamTypeWidened, ResultTypeNarrowed and
+	// ChanDirChanged.
+	SourceCompatible bool
+
+	// Returned holds the interface types of the old package that users
+	// need not implement, as computed by ReturnedInterfaces. Adding methods
+	// to them is compatible.
+	Returned map[*types.TypeName]bool
+
+	// TagKeys are the keys of struct tags, such as json, whose values
+	// are compared for exported fields. Changes are reported as TagChanged.
+	TagKeys []string
+
+	// UnkeyedLiterals reports the changes to struct types that only break
+	// unkeyed composite literals, such as T{1, 2}, as UnkeyedLiteralBroken:
+	// adding a field to a struct with only exported fields, or moving one.
+	// Otherwise, adding an exported field is compatible, and the other
+	// changes are reported as UnexportedFieldAdded and FieldRepositioned.
+	UnkeyedLiterals bool
+}
+
+// Compare returns the changes between x and y, the old and new versions
+// of an object. x is nil if the object was added, y if it was removed.
+func Compare(x, y types.Object, opts Options) []Change {
+	c := &comparer{opts: opts}
+	if x != nil && x.Pkg() != nil {
+		c.qx = types.RelativeTo(x.Pkg())
+	}
+	if y != nil && y.Pkg() != nil {
+		c.qy = types.RelativeTo(y.Pkg())
+		c.pkgy = y.Pkg()
+	}
+	c.object(x, y)
+	return c.changes
+}
+
+// breaks reports whether any of changes is incompatible.
+func breaks(changes []Change) bool {
+	for _, c := range changes {
+		if !c.Compatible {
+			return true
+		}
+	}
+	return false
+}
+
+// A comparer accumulates the changes found while comparing two objects.
+type comparer struct {
+	pi *ifacePair
+	ps *structPair
+
+	// qx and qy qualify type names in messages.
+	qx, qy types.Qualifier
+
+	// pkgy is the new version of the package, if known.
+	pkgy *types.Package
+
+	opts Options
+
+	// unnamed is set while comparing the underlying types of unexported
+	// defined types, whose composite literals other packages cannot write.
+	unnamed bool
+
+	changes []Change
+}
+
+func (c *comparer) report(kind Kind, path, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{
+		Kind: kind,
+		Path: path,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportCompatible is like report for a change that cannot break users.
+func (c *comparer) reportCompatible(kind Kind, path, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{
+		Kind:       kind,
+		Compatible: true,
+		Path:       path,
+		Msg:        fmt.Sprintf(format, args...),
+	})
+}
+
+// typeString returns the string form of t for messages. An alias is
+// spelled as the type it denotes, so that a type moved behind an alias
+// of the same name is not reported as changed from T to T.
+func typeString(t types.Type, q types.Qualifier) string {
+	return types.TypeString(types.Unalias(t), q)
+}
+
+func (c *comparer) identical(x, y types.Type) bool {
+	return c.ident(x, y, c.pi, c.ps)
+}
+
+func (c *comparer) object(x, y types.Object) {
+	if x == nil {
+		c.reportCompatible(Added, "", "%s added", objectKind(y))
+		return
+	}
+	if y == nil {
+		c.report(Removed, "", "%s removed", objectKind(x))
+		return
+	}
+	if objectKind(x) != objectKind(y) {
+		c.kindChange(x, y)
+		return
+	}
+	if x, ok := x.(*types.TypeName); ok {
+		c.typeName(x, y.(*types.TypeName))
+		return
+	}
+	if x, ok := x.(*types.Const); ok {
+		c.constant(x, y.(*types.Const))
+		return
+	}
+	if x, ok := x.(*types.Func); ok {
+		c.signature(x.Type().(*types.Signature), y.(*types.Func).Type().(*types.Signature), "", "", true)
+		return
+	}
+	c.typ(x.Type(), y.Type(), "")
+}
+
+// A kindChange describes an object changing from one kind to another.
+type kindChange struct {
+	kind       Kind
+	compatible bool
+	why        string // what the change breaks, if anything
+	typed      bool   // whether the types of the two objects are compared
+}
+
+// kindChanges holds the changes of kind of objects, indexed by the old and
+// new kinds as described by objectKind.
+//
+// A function may become a variable of the same type: the variable can be
+// used wherever the function could. A constant becoming a variable only
+// breaks constant expressions, such as array lengths, which is often
+// acceptable; the severity of ConstBecameVar can be lowered accordingly.
+var kindChanges = map[[2]string]kindChange{
+	{"constant", "variable"}: {ConstBecameVar, false, "so it can no longer be used in constant expressions", true},
+	{"constant", "function"}: {ConstBecameFunc, false, "so it can no longer be used in constant expressions", false},
+	{"constant", "type"}:     {ConstBecameType, false, "so it can no longer be used as a value", false},
+	{"variable", "constant"}: {VarBecameConst, false, "so it can no longer be assigned or addressed", true},
+	{"variable", "function"}: {VarBecameFunc, false, "so it can no longer be assigned or addressed", false},
+	{"variable", "type"}:     {VarBecameType, false, "so it can no longer be used as a value", false},
+	{"function", "constant"}: {FuncBecameConst, false, "so it can no longer be called", false},
+	{"function", "variable"}: {FuncBecameVar, true, "", true},
+	{"function", "type"}:     {FuncBecameType, false, "so it can no longer be called", false},
+	{"type", "constant"}:     {TypeBecameConst, false, "so it can no longer be used as a type", false},
+	{"type", "variable"}:     {TypeBecameVar, false, "so it can no longer be used as a type", false},
+	{"type", "function"}:     {TypeBecameFunc, false, "so it can no longer be used as a type", false},
+}
+
+// kindChange reports the change of kind of x into y, and compares their
+// types if they can still be used in the same way.
+func (c *comparer) kindChange(x, y types.Object) {
+	kx, ky := objectKind(x), objectKind(y)
+	k, ok := kindChanges[[2]string{kx, ky}]
+	if !ok {
+		c.report(KindChanged, "", "changed from %s to %s", kx, ky)
+		return
+	}
+	msg := fmt.Sprintf("changed from %s to %s", kx, ky)
+	if k.why != "" {
+		msg += ", " + k.why
+	}
+	if k.compatible {
+		c.reportCompatible(k.kind, "", "%s", msg)
+	} else {
+		c.report(k.kind, "", "%s", msg)
+	}
+	if k.typed {
+		// Untyped constants are compared by their default types,
+		// those of variables initialized with them.
+		c.typ(types.Default(x.Type()), types.Default(y.Type()), "")
+	}
+}
+
+// typeName compares two type declarations, either of which may be an alias.
+//
+// An alias for an identical type is compatible with the old declaration.
+// A defined type may become an alias for a defined type, which is how types
+// are moved to other packages, as long as the aliased type has the same
+// structure and methods; it breaks users if it becomes an alias for any
+// other type, such as a type literal, to which methods cannot be attached.
+// An alias becoming a defined type breaks users relying on the identity
+// of the two.
+func (c *comparer) typeName(x, y *types.TypeName) {
+	tx, ty := types.Unalias(x.Type()), types.Unalias(y.Type())
+	if types.Comparable(tx) && !types.Comparable(ty) {
+		c.report(ComparabilityLost, "", "no longer comparable, so it cannot be used with == or as a map key")
+	}
+	switch {
+	case x.IsAlias() && y.IsAlias():
+		c.typeParams(typeParamsOf(x.Type()), typeParamsOf(y.Type()), "")
+		c.typ(tx, ty, "")
+		return
+	case x.IsAlias():
+		c.report(AliasBecameDefined, "", "no longer an alias for %s", typeString(tx, c.qx))
+	case y.IsAlias():
+		if _, ok := ty.(*types.Named); ok {
+			c.reportCompatible(DefinedBecameAlias, "", "became an alias for %s", typeString(ty, c.qy))
+		} else {
+			c.report(DefinedBecameAlias, "", "became an alias for %s, which is not a defined type",
+				typeString(ty, c.qy))
+		}
+	}
+	c.typeParams(typeParamsOf(x.Type()), typeParamsOf(y.Type()), "")
+	if xi, ok := tx.Underlying().(*types.Interface); ok {
+		if yi, ok := ty.Underlying().(*types.Interface); ok {
+			if !xi.IsMethodSet() || !yi.IsMethodSet() {
+				c.constraint(xi, yi)
+			} else {
+				c.iface(xi, yi, "", c.opts.Returned[x])
+			}
+			return
+		}
+	}
+	c.unnamed = !x.Exported() && !x.IsAlias()
+	c.typ(tx.Underlying(), ty.Underlying(), "")
+	c.unnamed = false
+	c.methods(tx, ty)
+}
+
+// typeParamsOf returns the type parameters of a defined type or alias.
+func typeParamsOf(t types.Type) *types.TypeParamList {
+	switch t := t.(type) {
+	case *types.Named:
+		return t.TypeParams()
+	case *types.Alias:
+		return t.TypeParams()
+	}
+	return nil
+}
+
+// constant compares the types and values of two constants. An untyped
+// constant becoming typed is reported separately as it is no longer
+// assignable to other types.
+func (c *comparer) constant(x, y *types.Const) {
+	if isUntyped(x.Type()) && !isUntyped(y.Type()) {
+		c.report(ConstBecameTyped, "", "untyped constant became typed (%s)",
+			typeString(y.Type(), c.qy))
+	} else {
+		c.typ(x.Type(), y.Type(), "")
+	}
+
+	u, v := x.Val(), y.Val()
+	var equal bool
+	if u.Kind() == v.Kind() && u.Kind() != constant.Unknown {
+		equal = constant.Compare(u, token.EQL, v)
+	} else {
+		equal = u.ExactString() == v.ExactString()
+	}
+	if !equal {
+		c.report(ConstValueChanged, "", "value changed from %s to %s", u, v)
+	}
+}
+
+// typ compares x and y, breaking down the changes to functions, structs and
+// interfaces. Other types must be identical.
+func (c *comparer) typ(x, y types.Type, path string) {
+	switch x := x.(type) {
+	case *types.Signature:
+		if y, ok := y.(*types.Signature); ok {
+			c.signature(x, y, path, "", false)
+			return
+		}
+	case *types.Struct:
+		if y, ok := y.(*types.Struct); ok {
+			c.structure(x, y, path)
+			return
+		}
+	case *types.Interface:
+		if y, ok := y.(*types.Interface); ok {
+			c.iface(x, y, path, false)
+			return
+		}
+	}
+	if !c.identical(x, y) {
+		c.report(TypeChanged, path, "type changed from %s to %s",
+			typeString(x, c.qx), typeString(y, c.qy))
+	}
+}
+
+// signature compares two function types. owner describes the function
+// in messages if it is a method. call is set if the function can only be
+// called, as a declared function or method of a non-interface type, in
+// which case call-compatible changes are allowed in source-compatible mode.
+func (c *comparer) signature(x, y *types.Signature, path, owner string, call bool) {
+	relaxed := call && c.opts.SourceCompatible
+	c.typeParams(x.TypeParams(), y.TypeParams(), path)
+	xparams, yparams := x.Params(), y.Params()
+	switch {
+	case relaxed && !x.Variadic() && y.Variadic() && yparams.Len() == xparams.Len()+1:
+		// Calls cannot pass arguments for the new parameter.
+		i := xparams.Len()
+		c.reportCompatible(VariadicParamAdded, index(join(path, "params"), i),
+			"%svariadic parameter %d added", prefix(owner), i+1)
+		yparams = truncate(yparams, i)
+	case x.Variadic() != y.Variadic():
+		if x.Variadic() {
+			c.report(VariadicChanged, path, "%sno longer variadic", prefix(owner))
+		} else {
+			c.report(VariadicChanged, path, "%snow variadic", prefix(owner))
+		}
+	}
+
+	// Arguments must be assignable to the new parameters,
+	// and new results to what callers assign them to.
+	var widened, narrowed func(v, w types.Type) bool
+	if relaxed {
+		// Untyped constant arguments, such as 5, would take their default
+		// type, which does not implement the interface.
+		widened = func(v, w types.Type) bool {
+			_, basic := v.Underlying().(*types.Basic)
+			return !basic && c.implements(v, w)
+		}
+		// A concrete result breaks comparisons with nil, type assertions
+		// and type switches, unlike an interface with more methods.
+		narrowed = func(v, w types.Type) bool { return types.IsInterface(w) && c.implements(w, v) }
+	}
+	c.tuple(xparams, yparams, join(path, "params"), owner, paramKinds, call, widened)
+	c.tuple(x.Results(), y.Results(), join(path, "results"), owner, resultKinds, call, narrowed)
+}
+
+// tupleKinds are the kinds of changes to the parameters or results
+// of a function.
+type tupleKinds struct {
+	noun                    string
+	param                   bool
+	added, removed, changed Kind
+	relaxed                 Kind // compatible change in source-compatible mode
+}
+
+var (
+	paramKinds  = tupleKinds{"parameter", true, ParamAdded, ParamRemoved, ParamTypeChanged, ParamTypeWidened}
+	resultKinds = tupleKinds{"result", false, ResultAdded, ResultRemoved, ResultTypeChanged, ResultTypeNarrowed}
+)
+
+// tuple compares the parameters or results of two functions. call is as
+// for signature. If relaxed is not nil, a change of type from v to w for
+// which it returns true is reported as a compatible change.
+func (c *comparer) tuple(x, y *types.Tuple, path, owner string, k tupleKinds, call bool, relaxed func(v, w types.Type) bool) {
+	for i := y.Len(); i < x.Len(); i++ {
+		c.report(k.removed, index(path, i), "%s%s %d removed", prefix(owner), k.noun, i+1)
+	}
+	for i := x.Len(); i < y.Len(); i++ {
+		c.report(k.added, index(path, i), "%s%s %d added", prefix(owner), k.noun, i+1)
+	}
+	for i := 0; i < x.Len() && i < y.Len(); i++ {
+		v, w := x.At(i).Type(), y.At(i).Type()
+		if c.identical(v, w) {
+			continue
+		}
+		msg := fmt.Sprintf("%stype of %s %d changed from %s to %s",
+			prefix(owner), k.noun, i+1, typeString(v, c.qx), typeString(w, c.qy))
+		if cv, ok := v.(*types.Chan); ok {
+			if cw, ok := w.(*types.Chan); ok && c.identical(cv.Elem(), cw.Elem()) {
+				c.chanDir(cv, cw, index(path, i), msg, k.param, call)
+				continue
+			}
+		}
+		if relaxed != nil && relaxed(v, w) {
+			c.reportCompatible(k.relaxed, index(path, i), "%s", msg)
+			continue
+		}
+		c.report(k.changed, index(path, i), "%s", msg)
+	}
+}
+
+// chanDir compares the directions of two channel types with identical
+// elements, the types of a parameter if param is set, or else of a result.
+// msg describes the change.
+//
+// A parameter can accept more channels: a bidirectional channel can be
+// passed where a directional one is expected. Conversely, a result can be
+// made bidirectional, as it can be used as a directional channel. Such
+// changes are only compatible in source-compatible mode, for functions
+// that can only be called: any change breaks other uses of a function,
+// such as assigning it to a variable, and implementations of an interface
+// method. Changes in the other direction also break some callers.
+func (c *comparer) chanDir(x, y *types.Chan, path, msg string, param, call bool) {
+	var broken []string
+	if param {
+		// Callers pass bidirectional channels or channels of direction x.
+		if x.Dir() != types.SendRecv && x.Dir() != y.Dir() {
+			broken = append(broken, "callers passing "+dirNoun(x.Dir()))
+		}
+	} else if y.Dir() != types.SendRecv {
+		// Callers send on or receive from results, if x allows it.
+		if x.Dir() != types.RecvOnly && y.Dir() == types.RecvOnly {
+			broken = append(broken, "callers sending on it")
+		}
+		if x.Dir() != types.SendOnly && y.Dir() == types.SendOnly {
+			broken = append(broken, "callers receiving from it")
+		}
+	}
+	if !call {
+		broken = append(broken, "implementations")
+	} else if !c.opts.SourceCompatible {
+		broken = append(broken, "uses other than calls")
+	}
+	if len(broken) == 0 {
+		c.reportCompatible(ChanDirChanged, path, "%s", msg)
+		return
+	}
+	c.report(ChanDirChanged, path, "%s, breaking %s", msg, strings.Join(broken, " and "))
+}
+
+// dirNoun describes channels of direction dir.
+func dirNoun(dir types.ChanDir) string {
+	switch dir {
+	case types.SendOnly:
+		return "send-only channels"
+	case types.RecvOnly:
+		return "receive-only channels"
+	}
+	return "bidirectional channels"
+}
+
+// implements reports whether t, a type of the old version, implements the
+// interface iface of the new version: t has every method of iface, with
+// an identical signature.
+func (c *comparer) implements(t, iface types.Type) bool {
+	it, ok := iface.Underlying().(*types.Interface)
+	if !ok || !it.IsMethodSet() {
+		return false
+	}
+	mset := types.NewMethodSet(t)
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+		sel := mset.Lookup(m.Pkg(), m.Name())
+		if sel == nil || !c.identical(sel.Obj().Type(), m.Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// structure compares two struct types.
+//
+// A struct without exported fields can change freely, as can an empty one.
+// If the old struct has only exported fields, it may be used in unkeyed
+// composite literals: its exported fields must keep their position and no
+// unexported field can be added. In any case, exported fields must not be
+// removed and their types must not change.
+func (c *comparer) structure(x, y *types.Struct, path string) {
+	for i := 0; i < y.NumFields(); i++ {
+		f := y.Field(i)
+		if f.Exported() && fieldIndex(structFields(x), f.Name()) < 0 {
+			c.reportCompatible(FieldAdded, join(path, f.Name()), "field %s added", f.Name())
+			if c.opts.UnkeyedLiterals && !c.unnamed && x.NumFields() > 0 && allExported(x) {
+				c.report(UnkeyedLiteralBroken, join(path, f.Name()),
+					"field %s added to a struct with only exported fields", f.Name())
+			}
+		}
+	}
+	c.promoted(x, y, path)
+
+	if x.NumFields() == 0 {
+		return
+	}
+
+	var oldExported []*types.Var
+	var oldUnexportedNum int
+	for i := 0; i < x.NumFields(); i++ {
+		if x.Field(i).Exported() {
+			oldExported = append(oldExported, x.Field(i))
+		} else {
+			oldUnexportedNum++
+		}
+	}
+	if len(oldExported) == 0 {
+		return
+	}
+
+	var newExported []*types.Var
+	for i := 0; i < y.NumFields(); i++ {
+		f := y.Field(i)
+		if f.Exported() {
+			newExported = append(newExported, f)
+		} else if oldUnexportedNum == 0 {
+			c.unkeyed(UnexportedFieldAdded, join(path, f.Name()),
+				"unexported field %s added to a struct with only exported fields", f.Name())
+		}
+	}
+
+	for i, oldf := range oldExported {
+		j := fieldIndex(newExported, oldf.Name())
+		if j < 0 {
+			c.report(FieldRemoved, join(path, oldf.Name()), "field %s removed", oldf.Name())
+			continue
+		}
+		newf := newExported[j]
+		xs, xok := types.Unalias(oldf.Type()).(*types.Struct)
+		ys, yok := types.Unalias(newf.Type()).(*types.Struct)
+		switch {
+		case xok && yok:
+			// Anonymous struct types are compared field by field, so that
+			// changes to the tags of their fields are found.
+			c.structure(xs, ys, join(path, oldf.Name()))
+		case !c.identical(oldf.Type(), newf.Type()):
+			c.report(FieldTypeChanged, join(path, oldf.Name()), "type of field %s changed from %s to %s",
+				oldf.Name(), typeString(oldf.Type(), c.qx), typeString(newf.Type(), c.qy))
+		}
+		c.tags(fieldTag(x, oldf), fieldTag(y, newf), join(path, oldf.Name()), oldf.Name())
+		if oldUnexportedNum == 0 && i != j {
+			c.unkeyed(FieldRepositioned, join(path, oldf.Name()), "field %s moved from position %d to %d",
+				oldf.Name(), i+1, j+1)
+		}
+	}
+}
+
+// unkeyed reports a change of the given kind to a struct type that only
+// breaks its unkeyed composite literals, as UnkeyedLiteralBroken if set
+// in the options. Such changes to unexported types are not reported.
+func (c *comparer) unkeyed(kind Kind, path, format string, args ...interface{}) {
+	if c.unnamed {
+		return
+	}
+	if c.opts.UnkeyedLiterals {
+		kind = UnkeyedLiteralBroken
+	}
+	c.report(kind, path, format, args...)
+}
+
+// tags compares the values of the keys of interest in the tags of two
+// versions of a field, which encoding packages use to name and encode it.
+func (c *comparer) tags(x, y reflect.StructTag, path, field string) {
+	for _, key := range c.opts.TagKeys {
+		u, uok := x.Lookup(key)
+		v, vok := y.Lookup(key)
+		switch {
+		case uok && !vok:
+			c.report(TagChanged, path, "tag %s of field %s removed, was %q", key, field, u)
+		case !uok && vok:
+			c.report(TagChanged, path, "tag %s of field %s added (%q)", key, field, v)
+		case u != v:
+			c.report(TagChanged, path, "tag %s of field %s changed from %q to %q", key, field, u, v)
+		}
+	}
+}
+
+// promoted compares the exported fields promoted from the embedded fields
+// of two struct types, which users select like direct fields. A promoted
+// field must not be removed, change type, or become ambiguous because
+// another field of the same name is promoted from the same depth.
+func (c *comparer) promoted(x, y *types.Struct, path string) {
+	xnames, ynames := promotedFields(x), promotedFields(y)
+	for _, name := range xnames {
+		if fieldIndex(structFields(x), name) >= 0 {
+			continue
+		}
+		f, ok := lookupField(x, name)
+		if !ok || f == nil {
+			continue
+		}
+		g, ok := lookupField(y, name)
+		switch {
+		case !ok:
+			c.report(SelectorAmbiguous, join(path, name), "promoted field %s is now ambiguous", name)
+		case g == nil:
+			c.report(FieldRemoved, join(path, name), "promoted field %s removed", name)
+		case !c.identical(f.Type(), g.Type()):
+			c.report(FieldTypeChanged, join(path, name), "type of promoted field %s changed from %s to %s",
+				name, typeString(f.Type(), c.qx), typeString(g.Type(), c.qy))
+		}
+	}
+	for _, name := range ynames {
+		if fieldIndex(structFields(y), name) >= 0 {
+			continue
+		}
+		if g, ok := lookupField(y, name); ok && g != nil && !selects(x, name) {
+			c.reportCompatible(FieldAdded, join(path, name), "promoted field %s added", name)
+		}
+	}
+}
+
+// iface compares two interface types method by method. Adding a method
+// breaks implementers of the interface, and removing one breaks callers.
+// There are no implementers outside the package if the old interface is
+// sealed by an unexported method, and users need not implement it if
+// returned is set.
+func (c *comparer) iface(x, y *types.Interface, path string, returned bool) {
+	for i := 0; i < x.NumMethods(); i++ {
+		f := x.Method(i)
+		g := interfaceMethod(y, f.Name())
+		if g == nil {
+			c.report(MethodRemoved, join(path, f.Name()), "method %s removed from interface", f.Name())
+			continue
+		}
+		c.signature(f.Type().(*types.Signature), g.Type().(*types.Signature),
+			join(path, f.Name()), "method "+f.Name(), false)
+	}
+	for i := 0; i < y.NumMethods(); i++ {
+		g := y.Method(i)
+		if interfaceMethod(x, g.Name()) != nil {
+			continue
+		}
+		switch {
+		case sealed(x):
+			c.reportCompatible(MethodAdded, join(path, g.Name()),
+				"method %s added to sealed interface", g.Name())
+		case returned:
+			c.reportCompatible(MethodAdded, join(path, g.Name()),
+				"method %s added to interface only returned by the package", g.Name())
+		default:
+			c.report(MethodAdded, join(path, g.Name()), "method %s added to interface", g.Name())
+		}
+	}
+}
+
+// methods compares the exported methods of x and y, called through either
+// a value or a pointer.
+func (c *comparer) methods(x, y types.Type) {
+	if types.IsInterface(x) {
+		// Interface methods are compared by iface.
+		return
+	}
+	vx := types.NewMethodSet(x)
+	vy := types.NewMethodSet(y)
+	px := types.NewMethodSet(types.NewPointer(x))
+	py := types.NewMethodSet(types.NewPointer(y))
+
+	// The method set of *T contains that of T.
+	for i := 0; i < px.Len(); i++ {
+		f := px.At(i).Obj()
+		if !f.Exported() {
+			continue
+		}
+		g := py.Lookup(f.Pkg(), f.Name())
+		if g == nil {
+			if obj, index, _ := types.LookupFieldOrMethod(y, true, f.Pkg(), f.Name()); obj == nil && index != nil {
+				c.report(SelectorAmbiguous, f.Name(), "promoted method %s is now ambiguous", f.Name())
+			} else {
+				c.report(MethodRemoved, f.Name(), "method %s removed", f.Name())
+			}
+			continue
+		}
+		if vx.Lookup(f.Pkg(), f.Name()) != nil && vy.Lookup(f.Pkg(), f.Name()) == nil {
+			c.report(MethodReceiverChanged, f.Name(),
+				"method %s moved from a value receiver to a pointer receiver", f.Name())
+		}
+		c.signature(f.Type().(*types.Signature), g.Obj().Type().(*types.Signature),
+			f.Name(), "method "+f.Name(), true)
+	}
+
+	for i := 0; i < py.Len(); i++ {
+		g := py.At(i).Obj()
+		if g.Exported() && px.Lookup(g.Pkg(), g.Name()) == nil {
+			c.reportCompatible(MethodAddedToType, g.Name(), "method %s added", g.Name())
+		}
+	}
+}
+
+// interfaceMethod returns the method of iface with the given name,
+// or nil if there is none.
+func interfaceMethod(iface *types.Interface, name string) *types.Func {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if m := iface.Method(i); m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// sealed reports whether iface has an unexported method,
+// so that it cannot be implemented outside its package.
+func sealed(iface *types.Interface) bool {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate returns the first n variables of t.
+func truncate(t *types.Tuple, n int) *types.Tuple {
+	vars := make([]*types.Var, n)
+	for i := range vars {
+		vars[i] = t.At(i)
+	}
+	return types.NewTuple(vars...)
+}
+
+// promotedFields returns the names of the exported fields promoted from the
+// embedded fields of s, at any depth, whether ambiguous or not.
+func promotedFields(s *types.Struct) []string {
+	var names []string
+	seen := make(map[string]bool)
+	embedded := make(map[*types.Named]bool)
+	var walk func(s *types.Struct, depth int)
+	walk = func(s *types.Struct, depth int) {
+		for i := 0; i < s.NumFields(); i++ {
+			f := s.Field(i)
+			if depth > 0 && f.Exported() && !seen[f.Name()] {
+				seen[f.Name()] = true
+				names = append(names, f.Name())
+			}
+			if !f.Embedded() {
+				continue
+			}
+			t := types.Unalias(f.Type())
+			if p, ok := t.(*types.Pointer); ok {
+				t = types.Unalias(p.Elem())
+			}
+			if n, ok := t.(*types.Named); ok {
+				if embedded[n] {
+					continue
+				}
+				embedded[n] = true
+			}
+			if es, ok := t.Underlying().(*types.Struct); ok {
+				walk(es, depth+1)
+			}
+		}
+	}
+	walk(s, 0)
+	return names
+}
+
+// lookupField returns the field selected by name in a value of struct type s,
+// or nil if name selects nothing or a method. It returns false if name is
+// ambiguous.
+func lookupField(s *types.Struct, name string) (*types.Var, bool) {
+	obj, index, _ := types.LookupFieldOrMethod(s, true, nil, name)
+	if obj == nil {
+		return nil, index == nil
+	}
+	f, _ := obj.(*types.Var)
+	return f, true
+}
+
+// selects reports whether name selects a field or method, even ambiguously,
+// in a value of struct type s.
+func selects(s *types.Struct, name string) bool {
+	obj, index, _ := types.LookupFieldOrMethod(s, true, nil, name)
+	return obj != nil || index != nil
+}
+
+// allExported reports whether every field of s is exported.
+func allExported(s *types.Struct) bool {
+	for i := 0; i < s.NumFields(); i++ {
+		if !s.Field(i).Exported() {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldTag returns the tag of field f of s.
+func fieldTag(s *types.Struct, f *types.Var) reflect.StructTag {
+	for i := 0; i < s.NumFields(); i++ {
+		if s.Field(i) == f {
+			return reflect.StructTag(s.Tag(i))
+		}
+	}
+	return ""
+}
+
+func structFields(s *types.Struct) []*types.Var {
+	fields := make([]*types.Var, s.NumFields())
+	for i := range fields {
+		fields[i] = s.Field(i)
+	}
+	return fields
+}
+
+func fieldIndex(fields []*types.Var, name string) int {
+	for i, f := range fields {
+		if f.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// objectKind describes the kind of obj in messages.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.TypeName:
+		return "type"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	}
+	return "object"
+}
+
+func isUntyped(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Info()&types.IsUntyped != 0
+}
+
+func join(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
+
+func index(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+func prefix(owner string) string {
+	if owner == "" {
+		return ""
+	}
+	return owner + ": "
+}
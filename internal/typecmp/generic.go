@@ -0,0 +1,211 @@
+package typecmp
+
+import "go/types"
+
+func (c *comparer) identTypeParams(x, y *types.TypeParamList, pi *ifacePair, ps *structPair) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !c.ident(x.At(i).Constraint(), y.At(i).Constraint(), pi, ps) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *comparer) identTypeList(x, y *types.TypeList, pi *ifacePair, ps *structPair) bool {
+	if x.Len() != y.Len() {
+		return false
+	}
+	for i := 0; i < x.Len(); i++ {
+		if !c.ident(x.At(i), y.At(i), pi, ps) {
+			return false
+		}
+	}
+	return true
+}
+
+// A typeSet approximates the type set of a constraint interface:
+// the types that have all of its methods, are comparable if required,
+// and match one of its terms, unless all types are allowed.
+type typeSet struct {
+	methods    []*types.Func
+	comparable bool
+	terms      []*types.Term
+	all        bool
+}
+
+func newTypeSet(iface *types.Interface) *typeSet {
+	s := &typeSet{
+		comparable: iface.IsComparable(),
+		all:        true,
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		s.methods = append(s.methods, iface.Method(i))
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var terms []*types.Term
+		switch t := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			terms = unionTerms(t)
+		default:
+			if u, ok := t.Underlying().(*types.Interface); ok {
+				es := newTypeSet(u)
+				if es.all {
+					continue
+				}
+				terms = es.terms
+			} else {
+				terms = []*types.Term{types.NewTerm(false, t)}
+			}
+		}
+		if s.all {
+			s.terms, s.all = terms, false
+		} else {
+			s.terms = intersectTerms(s.terms, terms)
+		}
+	}
+	return s
+}
+
+// subset reports whether every type in s is also in t.
+func (s *typeSet) subset(t *typeSet) bool {
+	for _, g := range t.methods {
+		found := false
+		for _, f := range s.methods {
+			if f.Name() == g.Name() && identical(f.Type(), g.Type()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if t.comparable && !s.comparable {
+		return false
+	}
+	if t.all {
+		return true
+	}
+	if s.all {
+		return false
+	}
+	for _, x := range s.terms {
+		if !coveredBy(x, t.terms) {
+			return false
+		}
+	}
+	return true
+}
+
+func unionTerms(u *types.Union) []*types.Term {
+	terms := make([]*types.Term, u.Len())
+	for i := range terms {
+		terms[i] = u.Term(i)
+	}
+	return terms
+}
+
+// covers reports whether every type matching term x also matches term y.
+func covers(y, x *types.Term) bool {
+	if y.Tilde() {
+		return identical(x.Type().Underlying(), y.Type())
+	}
+	return !x.Tilde() && identical(x.Type(), y.Type())
+}
+
+func coveredBy(x *types.Term, terms []*types.Term) bool {
+	for _, y := range terms {
+		if covers(y, x) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectTerms(x, y []*types.Term) []*types.Term {
+	var terms []*types.Term
+	for _, t := range x {
+		if coveredBy(t, y) {
+			terms = append(terms, t)
+		}
+	}
+	for _, t := range y {
+		if coveredBy(t, x) && !coveredBy(t, terms) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func identTerms(x, y []*types.Term) bool {
+	for _, t := range x {
+		if !coveredBy(t, y) {
+			return false
+		}
+	}
+	for _, t := range y {
+		if !coveredBy(t, x) {
+			return false
+		}
+	}
+	return true
+}
+
+// identTypeSet reports whether the non-method parts of the type sets of
+// two interfaces are identical. Methods are compared by ident.
+func identTypeSet(x, y *types.Interface) bool {
+	if x.IsMethodSet() && y.IsMethodSet() {
+		return true
+	}
+	s, t := newTypeSet(x), newTypeSet(y)
+	return s.comparable == t.comparable && s.all == t.all && identTerms(s.terms, t.terms)
+}
+
+// typeParams compares the type parameters of two generic declarations.
+// A constraint can be loosened but not tightened, as every type argument
+// that satisfied the old constraint must satisfy the new one.
+func (c *comparer) typeParams(x, y *types.TypeParamList, path string) {
+	for i := y.Len(); i < x.Len(); i++ {
+		c.report(TypeParamRemoved, index(join(path, "typeparams"), i),
+			"type parameter %s removed", x.At(i).Obj().Name())
+	}
+	for i := x.Len(); i < y.Len(); i++ {
+		c.report(TypeParamAdded, index(join(path, "typeparams"), i),
+			"type parameter %s added", y.At(i).Obj().Name())
+	}
+	for i := 0; i < x.Len() && i < y.Len(); i++ {
+		u, v := x.At(i).Constraint(), y.At(i).Constraint()
+		if c.identical(u, v) {
+			continue
+		}
+		ui, uok := u.Underlying().(*types.Interface)
+		vi, vok := v.Underlying().(*types.Interface)
+		if uok && vok && newTypeSet(ui).subset(newTypeSet(vi)) {
+			c.reportCompatible(ConstraintLoosened, index(join(path, "typeparams"), i),
+				"constraint of type parameter %s loosened from %s to %s", x.At(i).Obj().Name(),
+				typeString(u, c.qx), typeString(v, c.qy))
+			continue
+		}
+		c.report(ConstraintTightened, index(join(path, "typeparams"), i),
+			"constraint of type parameter %s changed from %s to %s", x.At(i).Obj().Name(),
+			typeString(u, c.qx), typeString(v, c.qy))
+	}
+}
+
+// constraint compares two interfaces that can only be used as constraints,
+// as their type sets are not plain method sets. Like the constraint of
+// a type parameter, the type set can be loosened but not tightened.
+func (c *comparer) constraint(x, y *types.Interface) {
+	s, t := newTypeSet(x), newTypeSet(y)
+	switch {
+	case !s.subset(t):
+		c.report(ConstraintTightened, "", "type set changed from %s to %s",
+			typeString(x, c.qx), typeString(y, c.qy))
+	case !t.subset(s):
+		c.reportCompatible(ConstraintLoosened, "", "type set loosened from %s to %s",
+			typeString(x, c.qx), typeString(y, c.qy))
+	}
+}
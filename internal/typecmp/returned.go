@@ -0,0 +1,195 @@
+package typecmp
+
+import "go/types"
+
+// ReturnedInterfaces returns the interface types of pkg, exported or
+// reachable from its exported API, that the API only ever returns to users,
+// and never accepts from them. Users have no reason to implement such an
+// interface, so methods can be added to it.
+//
+// A type is returned if some other part of the API provides values of it
+// to users, such as the results of exported functions. A type is accepted
+// if users can provide values of it to the package: as arguments to
+// exported functions and methods, as results of functions and interface
+// methods they implement, through exported variables and struct fields, or
+// as type arguments satisfying a constraint. An interface that the API
+// does not otherwise use may be implemented by users, so it is not
+// returned.
+func ReturnedInterfaces(pkg *types.Package) map[*types.TypeName]bool {
+	w := walkAPI(pkg)
+	returned := make(map[*types.TypeName]bool)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() || !w.returned[obj] || w.accepted[obj] {
+			continue
+		}
+		if _, ok := obj.Type().Underlying().(*types.Interface); ok {
+			returned[obj] = true
+		}
+	}
+	return returned
+}
+
+// ReachableTypes returns the unexported types declared at the package level
+// of pkg that are reachable from its exported API, such as an unexported
+// struct type returned by an exported function or embedded in an exported
+// struct type. Users cannot name these types, but can use their exported
+// fields and methods, and implement or compare them.
+func ReachableTypes(pkg *types.Package) map[*types.TypeName]bool {
+	w := walkAPI(pkg)
+	reachable := make(map[*types.TypeName]bool)
+	for obj := range w.seen {
+		if !obj.Exported() && obj.Parent() == pkg.Scope() {
+			reachable[obj] = true
+		}
+	}
+	return reachable
+}
+
+// walkAPI walks the exported API of pkg.
+func walkAPI(pkg *types.Package) *apiWalker {
+	w := &apiWalker{
+		pkg:      pkg,
+		accepted: make(map[*types.TypeName]bool),
+		returned: make(map[*types.TypeName]bool),
+		seen:     make(map[*types.TypeName]bool),
+	}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const, *types.Func:
+			w.walk(obj.Type(), false)
+		case *types.Var:
+			w.walk(obj.Type(), false)
+			w.walk(obj.Type(), true)
+		case *types.TypeName:
+			w.decl(obj)
+		}
+	}
+	return w
+}
+
+// An apiWalker finds the named types of a package that its API refers to,
+// and those that it returns and accepts.
+type apiWalker struct {
+	pkg      *types.Package
+	accepted map[*types.TypeName]bool
+	returned map[*types.TypeName]bool
+	seen     map[*types.TypeName]bool // declarations walked
+}
+
+// decl walks the declaration of a type of the package.
+func (w *apiWalker) decl(obj *types.TypeName) {
+	if w.seen[obj] {
+		return
+	}
+	w.seen[obj] = true
+
+	// Values of the type flow both ways.
+	u := types.Unalias(obj.Type())
+	if !obj.IsAlias() {
+		u = u.Underlying()
+	}
+	w.walk(u, false)
+	w.walk(u, true)
+	if named, ok := obj.Type().(*types.Named); ok {
+		w.typeParams(named.TypeParams())
+	}
+
+	if named, ok := obj.Type().(*types.Named); ok && !types.IsInterface(named) {
+		for i := 0; i < named.NumMethods(); i++ {
+			if m := named.Method(i); m.Exported() {
+				w.walk(m.Type(), false)
+			}
+		}
+	}
+}
+
+// walk walks t, which users provide to the package if provided is set,
+// and receive from it otherwise.
+func (w *apiWalker) walk(t types.Type, provided bool) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		obj := t.Origin().Obj()
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			w.walk(args.At(i), false)
+			w.walk(args.At(i), true)
+		}
+		if obj.Pkg() != w.pkg {
+			return
+		}
+		if provided {
+			w.accepted[obj] = true
+		} else {
+			w.returned[obj] = true
+		}
+		// Exported declarations are walked on their own.
+		if !obj.Exported() {
+			w.decl(obj)
+		}
+
+	case *types.Pointer:
+		w.walk(t.Elem(), provided)
+	case *types.Slice:
+		w.walk(t.Elem(), provided)
+	case *types.Array:
+		w.walk(t.Elem(), provided)
+	case *types.Chan:
+		w.walk(t.Elem(), provided)
+	case *types.Map:
+		w.walk(t.Key(), provided)
+		w.walk(t.Elem(), provided)
+
+	case *types.Signature:
+		w.typeParams(t.TypeParams())
+		// The caller of a function provides its arguments
+		// and receives its results.
+		for i := 0; i < t.Params().Len(); i++ {
+			w.walk(t.Params().At(i).Type(), !provided)
+		}
+		for i := 0; i < t.Results().Len(); i++ {
+			w.walk(t.Results().At(i).Type(), provided)
+		}
+
+	case *types.Struct:
+		// Fields can be both read and written.
+		for i := 0; i < t.NumFields(); i++ {
+			if f := t.Field(i); f.Exported() || f.Embedded() {
+				w.walk(f.Type(), false)
+				w.walk(f.Type(), true)
+			}
+		}
+
+	case *types.Interface:
+		// Methods can be both called and implemented.
+		for i := 0; i < t.NumMethods(); i++ {
+			if m := t.Method(i); m.Exported() {
+				w.walk(m.Type(), false)
+				w.walk(m.Type(), true)
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			w.walk(t.EmbeddedType(i), false)
+			w.walk(t.EmbeddedType(i), true)
+		}
+
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			w.walk(t.Term(i).Type(), provided)
+		}
+	}
+}
+
+// typeParams walks the constraints of a list of type parameters, which
+// users satisfy with their type arguments.
+func (w *apiWalker) typeParams(list *types.TypeParamList) {
+	for i := 0; i < list.Len(); i++ {
+		w.walk(list.At(i).Constraint(), true)
+	}
+}
@@ -28,24 +28,12 @@
 
 package typecmp
 
-import (
-	"go/types"
-	"reflect"
-)
+import "go/types"
 
-func Compatible(x, y types.Object) bool {
-	if y == nil {
-		return false
-	}
-	if reflect.TypeOf(x.Type()) != reflect.TypeOf(y.Type()) {
-		return false
-	}
-	return ident(x.Type(), y.Type(), nil, nil)
-}
-
-// identical reports whether x and y are identical.
+// identical reports whether x and y are identical, comparing named types
+// by qualified name.
 func identical(x, y types.Type) bool {
-	return ident(x, y, nil, nil)
+	return new(comparer).ident(x, y, nil, nil)
 }
 
 // An ifacePair is a node in a stack of interface type pairs compared for identity.
@@ -67,7 +55,9 @@ func (p *structPair) identical(q *structPair) bool {
 	return p.x == q.x && p.y == q.y || p.x == q.y && p.y == q.x
 }
 
-func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
+func (c *comparer) ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
+	// An alias such as any denotes the type it stands for.
+	x, y = types.Unalias(x), types.Unalias(y)
 	if x == y {
 		return true
 	}
@@ -85,13 +75,13 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 		// Two array types are identical if they have identical element types
 		// and the same array length.
 		if y, ok := y.(*types.Array); ok {
-			return x.Len() == y.Len() && ident(x.Elem(), y.Elem(), pi, ps)
+			return x.Len() == y.Len() && c.ident(x.Elem(), y.Elem(), pi, ps)
 		}
 
 	case *types.Slice:
 		// Two slice types are identical if they have identical element types.
 		if y, ok := y.(*types.Slice); ok {
-			return ident(x.Elem(), y.Elem(), pi, ps)
+			return c.ident(x.Elem(), y.Elem(), pi, ps)
 		}
 
 	case *types.Struct:
@@ -100,34 +90,34 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 		// and identical tags. Two anonymous fields are considered to have the same
 		// name. Lower-case field names from different packages are always different.
 		if y, ok := y.(*types.Struct); ok {
-			if x.NumFields() == y.NumFields() {
-				qs := &structPair{x, y, ps}
-				for ps != nil {
-					if ps.identical(qs) {
-						return true // same pair was compared before
-					}
-					ps = ps.prev
+			qs := &structPair{x, y, ps}
+			for ps != nil {
+				if ps.identical(qs) {
+					return true // same pair was compared before
 				}
+				ps = ps.prev
+			}
+			if x.NumFields() == y.NumFields() {
 				for i := 0; i < x.NumFields(); i++ {
 					f := x.Field(i)
 					g := y.Field(i)
 					if f.Anonymous() != g.Anonymous() ||
 						x.Tag(i) != y.Tag(i) ||
 						f.Name() != g.Name() ||
-						!ident(f.Type(), g.Type(), pi, qs) {
+						!c.ident(f.Type(), g.Type(), pi, qs) {
 						goto compat
 					}
 				}
 				return true
 			}
 		compat:
-			return structcompat(x, y)
+			return c.structcompat(x, y, pi, qs)
 		}
 
 	case *types.Pointer:
 		// Two pointer types are identical if they have identical base types.
 		if y, ok := y.(*types.Pointer); ok {
-			return ident(x.Elem(), y.Elem(), pi, ps)
+			return c.ident(x.Elem(), y.Elem(), pi, ps)
 		}
 
 	case *types.Tuple:
@@ -139,7 +129,7 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 					for i := 0; i < x.Len(); i++ {
 						v := x.At(i)
 						w := y.At(i)
-						if !ident(v.Type(), w.Type(), pi, ps) {
+						if !c.ident(v.Type(), w.Type(), pi, ps) {
 							return false
 						}
 					}
@@ -152,18 +142,24 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 		// Two function types are identical if they have the same number of parameters
 		// and result values, corresponding parameter and result types are identical,
 		// and either both functions are variadic or neither is. Parameter and result
-		// names are not required to match.
+		// names are not required to match. Generic functions must also have the
+		// same number of type parameters with identical constraints.
 		if y, ok := y.(*types.Signature); ok {
 			return x.Variadic() == y.Variadic() &&
-				ident(x.Params(), y.Params(), pi, ps) &&
-				ident(x.Results(), y.Results(), pi, ps)
+				c.identTypeParams(x.TypeParams(), y.TypeParams(), pi, ps) &&
+				c.ident(x.Params(), y.Params(), pi, ps) &&
+				c.ident(x.Results(), y.Results(), pi, ps)
 		}
 
 	case *types.Interface:
 		// Two interface types are identical if they have the same set of methods with
 		// the same names and identical function types. Lower-case method names from
 		// different packages are always different. The order of the methods is irrelevant.
+		// Constraint interfaces must also have identical type sets.
 		if y, ok := y.(*types.Interface); ok {
+			if !identTypeSet(x, y) {
+				return false
+			}
 			if x.NumMethods() == y.NumMethods() {
 				// Interface types are the only types where cycles can occur
 				// that are not "terminated" via named types; and such cycles
@@ -196,8 +192,8 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 				}
 				for i := 0; i < x.NumMethods(); i++ {
 					f := x.Method(i)
-					g := y.Method(i)
-					if f.Name() != g.Name() || !ident(f.Type(), g.Type(), qi, ps) {
+					g := interfaceMethod(y, f.Name())
+					if g == nil || !c.ident(f.Type(), g.Type(), qi, ps) {
 						return false
 					}
 				}
@@ -208,18 +204,47 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 	case *types.Map:
 		// Two map types are identical if they have identical key and value types.
 		if y, ok := y.(*types.Map); ok {
-			return ident(x.Key(), y.Key(), pi, ps) && ident(x.Elem(), y.Elem(), pi, ps)
+			return c.ident(x.Key(), y.Key(), pi, ps) && c.ident(x.Elem(), y.Elem(), pi, ps)
 		}
 
 	case *types.Chan:
 		// Two channel types are identical if they have identical value types
 		// and the same direction.
 		if y, ok := y.(*types.Chan); ok {
-			return x.Dir() == y.Dir() && ident(x.Elem(), y.Elem(), pi, ps)
+			return x.Dir() == y.Dir() && c.ident(x.Elem(), y.Elem(), pi, ps)
 		}
 
 	case *types.Named:
-		return ident(x.Underlying(), y.Underlying(), pi, ps)
+		// Two named types are identical if they have the same name and are
+		// declared in packages with the same path, whatever the version of
+		// the package. Leniently, they are identical if their underlying
+		// types are, and a named type is identical to its underlying type.
+		// Instances of generic types must also have identical type arguments.
+		if y, ok := y.(*types.Named); ok {
+			if !c.identTypeList(x.TypeArgs(), y.TypeArgs(), pi, ps) {
+				return false
+			}
+			if !c.opts.LenientNamed {
+				return sameTypeName(x.Obj(), y.Obj()) || c.becameAlias(x.Obj(), y.Obj())
+			}
+		}
+		if c.opts.LenientNamed {
+			return c.ident(x.Underlying(), y.Underlying(), pi, ps)
+		}
+
+	case *types.TypeParam:
+		// Type parameters of different versions of a declaration are
+		// identical if they are at the same position, whatever their name.
+		// Constraints are compared along with the declaring type parameter list.
+		if y, ok := y.(*types.TypeParam); ok {
+			return x.Index() == y.Index()
+		}
+
+	case *types.Union:
+		// Two unions are identical if they have the same set of terms.
+		if y, ok := y.(*types.Union); ok {
+			return identTerms(unionTerms(x), unionTerms(y))
+		}
 
 	default:
 		panic("unreachable")
@@ -228,57 +253,40 @@ func ident(x, y types.Type, pi *ifacePair, ps *structPair) bool {
 	return false
 }
 
-func structcompat(x, y *types.Struct) bool {
-	if x.NumFields() == 0 {
-		return true
-	}
-
-	var oldExported []*types.Var
-	var oldUnexportedNum int
-	for i := 0; i < x.NumFields(); i++ {
-		if x.Field(i).Exported() {
-			oldExported = append(oldExported, x.Field(i))
-		} else {
-			oldUnexportedNum++
-		}
+// sameTypeName reports whether x and y are versions of the same type name.
+func sameTypeName(x, y *types.TypeName) bool {
+	if x.Name() != y.Name() {
+		return false
 	}
-	if len(oldExported) == 0 {
-		return true
+	if x.Pkg() == nil || y.Pkg() == nil {
+		// Predeclared, such as error.
+		return x.Pkg() == y.Pkg()
 	}
+	return x.Pkg().Path() == y.Pkg().Path()
+}
 
-	var newExported []*types.Var
-	for i := 0; i < y.NumFields(); i++ {
-		if y.Field(i).Exported() {
-			newExported = append(newExported, y.Field(i))
-		} else if oldUnexportedNum == 0 { // oldExportedNum > 0
-			// The old struct does not have unexported fields
-			// but the new struct does
-			return false
-		}
+// becameAlias reports whether x, a defined type of the old package, became
+// an alias for the defined type y in the new package, so that the two are
+// the same type for users of x.
+func (c *comparer) becameAlias(x, y *types.TypeName) bool {
+	if c.pkgy == nil || x.Pkg() == nil || x.Pkg().Path() != c.pkgy.Path() {
+		return false
 	}
-
-	for i, oldf := range oldExported {
-		// If the old struct does not have unexported fields,
-		// the order of the exported fields must be preserved.
-		// In any case, exported fields must not be removed.
-		if oldUnexportedNum == 0 {
-			if i >= len(newExported) {
-				return false
-			}
-			newf := newExported[i]
-			if newf.Name() != oldf.Name() || !identical(oldf.Type(), newf.Type()) {
-				return false
-			}
-		} else {
-			for _, f := range newExported {
-				if f.Name() == oldf.Name() {
-					return identical(oldf.Type(), f.Type())
-				}
-			}
-			// No matching field in newExported
-			return false
-		}
+	alias, ok := c.pkgy.Scope().Lookup(x.Name()).(*types.TypeName)
+	if !ok || !alias.IsAlias() {
+		return false
 	}
+	named, ok := types.Unalias(alias.Type()).(*types.Named)
+	return ok && sameTypeName(named.Origin().Obj(), y)
+}
 
-	return true
+// structcompat reports whether y is a compatible replacement for x.
+// The rules are those of (*comparer).structure.
+func (c *comparer) structcompat(x, y *types.Struct, pi *ifacePair, ps *structPair) bool {
+	// Tags and unkeyed literals do not affect compatibility.
+	sc := &comparer{pi: pi, ps: ps, opts: c.opts}
+	sc.opts.TagKeys = nil
+	sc.opts.UnkeyedLiterals = false
+	sc.structure(x, y, "")
+	return !breaks(sc.changes)
 }
@@ -0,0 +1,113 @@
+package breaking
+
+import (
+	"strconv"
+
+	"github.com/sprt/breaking/internal/typecmp"
+)
+
+// A ChangeKind identifies the rule that reported a change.
+type ChangeKind = typecmp.Kind
+
+// Kinds of changes.
+const (
+	Removed               ChangeKind = typecmp.Removed               // name removed
+	KindChanged           ChangeKind = typecmp.KindChanged           // kind of a name changed, other than below
+	TypeChanged           ChangeKind = typecmp.TypeChanged           // type of a variable or constant, or underlying type
+	ParamAdded            ChangeKind = typecmp.ParamAdded            // parameter added to a function
+	ParamRemoved          ChangeKind = typecmp.ParamRemoved          // parameter removed from a function
+	ParamTypeChanged      ChangeKind = typecmp.ParamTypeChanged      // type of a parameter changed
+	ResultAdded           ChangeKind = typecmp.ResultAdded           // result added to a function
+	ResultRemoved         ChangeKind = typecmp.ResultRemoved         // result removed from a function
+	ResultTypeChanged     ChangeKind = typecmp.ResultTypeChanged     // type of a result changed
+	VariadicChanged       ChangeKind = typecmp.VariadicChanged       // function became or stopped being variadic
+	FieldRemoved          ChangeKind = typecmp.FieldRemoved          // exported struct field removed
+	FieldTypeChanged      ChangeKind = typecmp.FieldTypeChanged      // type of an exported struct field changed
+	FieldRepositioned     ChangeKind = typecmp.FieldRepositioned     // field moved in a struct with only exported fields
+	UnexportedFieldAdded  ChangeKind = typecmp.UnexportedFieldAdded  // unexported field added to a struct with only exported fields
+	MethodAdded           ChangeKind = typecmp.MethodAdded           // method added to an interface (compatible if sealed or only returned)
+	MethodRemoved         ChangeKind = typecmp.MethodRemoved         // method removed from an interface or type
+	MethodReceiverChanged ChangeKind = typecmp.MethodReceiverChanged // method moved from a value to a pointer receiver
+	ConstValueChanged     ChangeKind = typecmp.ConstValueChanged     // value of a constant changed
+	ConstBecameTyped      ChangeKind = typecmp.ConstBecameTyped      // untyped constant became typed
+	TypeParamAdded        ChangeKind = typecmp.TypeParamAdded        // type parameter added to a generic function or type
+	TypeParamRemoved      ChangeKind = typecmp.TypeParamRemoved      // type parameter removed from a generic function or type
+	ConstraintTightened   ChangeKind = typecmp.ConstraintTightened   // constraint of a type parameter, or type set of a constraint interface, no longer satisfied by all old type arguments
+	Added                 ChangeKind = typecmp.Added                 // name added (compatible)
+	FieldAdded            ChangeKind = typecmp.FieldAdded            // exported struct field added (compatible)
+	MethodAddedToType     ChangeKind = typecmp.MethodAddedToType     // method added to a non-interface type (compatible)
+	ConstraintLoosened    ChangeKind = typecmp.ConstraintLoosened    // constraint of a type parameter, or type set of a constraint interface, loosened (compatible)
+	PackageRemoved        ChangeKind = typecmp.PackageRemoved        // package removed from a tree
+	PackageAdded          ChangeKind = typecmp.PackageAdded          // package added to a tree (compatible)
+	DefinedBecameAlias    ChangeKind = typecmp.DefinedBecameAlias    // defined type became an alias (compatible if for a defined type)
+	AliasBecameDefined    ChangeKind = typecmp.AliasBecameDefined    // alias became a defined type
+	VariadicParamAdded    ChangeKind = typecmp.VariadicParamAdded    // final variadic parameter added (compatible with SourceCompatible)
+	ParamTypeWidened      ChangeKind = typecmp.ParamTypeWidened      // parameter changed to an interface the old type implements (compatible with SourceCompatible)
+	ResultTypeNarrowed    ChangeKind = typecmp.ResultTypeNarrowed    // interface result changed to an interface with all of its methods (compatible with SourceCompatible)
+	SelectorAmbiguous     ChangeKind = typecmp.SelectorAmbiguous     // promoted field or method became ambiguous
+	TagChanged            ChangeKind = typecmp.TagChanged            // struct tag of an exported field changed (serialization)
+	ComparabilityLost     ChangeKind = typecmp.ComparabilityLost     // type no longer comparable
+	UnkeyedLiteralBroken  ChangeKind = typecmp.UnkeyedLiteralBroken  // unkeyed struct literals broken (warning, with SeparateUnkeyedLiterals)
+	ChanDirChanged        ChangeKind = typecmp.ChanDirChanged        // direction of a channel parameter or result changed (compatible if widened, with SourceCompatible)
+	ConstBecameVar        ChangeKind = typecmp.ConstBecameVar        // constant became a variable
+	ConstBecameFunc       ChangeKind = typecmp.ConstBecameFunc       // constant became a function
+	ConstBecameType       ChangeKind = typecmp.ConstBecameType       // constant became a type
+	VarBecameConst        ChangeKind = typecmp.VarBecameConst        // variable became a constant
+	VarBecameFunc         ChangeKind = typecmp.VarBecameFunc         // variable became a function
+	VarBecameType         ChangeKind = typecmp.VarBecameType         // variable became a type
+	FuncBecameConst       ChangeKind = typecmp.FuncBecameConst       // function became a constant
+	FuncBecameVar         ChangeKind = typecmp.FuncBecameVar         // function became a variable (compatible)
+	FuncBecameType        ChangeKind = typecmp.FuncBecameType        // function became a type
+	TypeBecameConst       ChangeKind = typecmp.TypeBecameConst       // type became a constant
+	TypeBecameVar         ChangeKind = typecmp.TypeBecameVar         // type became a variable
+	TypeBecameFunc        ChangeKind = typecmp.TypeBecameFunc        // type became a function
+)
+
+// A Severity tells how a change affects users of a package.
+type Severity int
+
+const (
+	// Breaking changes may stop users' code from compiling.
+	Breaking Severity = iota
+
+	// Warnings do not stop users' code from compiling
+	// but may change its behavior.
+	Warning
+
+	// Compatible changes, such as additions, cannot break users' code.
+	// They are only reported with IncludeCompatible.
+	Compatible
+
+	// Serialization changes do not stop users' code from compiling
+	// but change how values are encoded, breaking compatibility with
+	// data encoded by the old version, e.g. changed struct tags.
+	Serialization
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Breaking:
+		return "breaking"
+	case Warning:
+		return "warning"
+	case Compatible:
+		return "compatible"
+	case Serialization:
+		return "serialization"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// defaultSeverity returns the severity of an incompatible change of the
+// given kind when not set by WithSeverity.
+func defaultSeverity(kind ChangeKind) Severity {
+	switch kind {
+	case ConstValueChanged:
+		return Warning
+	case TagChanged:
+		return Serialization
+	case UnkeyedLiteralBroken:
+		return Warning
+	}
+	return Breaking
+}
@@ -0,0 +1,188 @@
+package breaking
+
+import (
+	"errors"
+	"go/build"
+	"go/types"
+
+	"github.com/sprt/breaking/internal/typecmp"
+)
+
+// An Option configures ComparePackages.
+type Option func(*options)
+
+type options struct {
+	severity   map[ChangeKind]Severity
+	disabled   map[ChangeKind]bool
+	strict     bool // incompatible changes are breaking unless in severity
+	compatible bool
+	cmp        typecmp.Options
+	ctxt       *build.Context // nil for build.Default
+	tags       []string
+	platforms  []string       // GOOS/GOARCH pairs
+	importer   types.Importer // nil for the module and standard importers
+
+	allowTypeErrors bool
+	onTypeError     func(*CheckError)
+}
+
+// defaultTagKeys are the struct tag keys of common encodings.
+var defaultTagKeys = []string{"json", "xml", "yaml", "protobuf"}
+
+func newOptions(opts []Option) *options {
+	o := &options{severity: make(map[ChangeKind]Severity)}
+	o.cmp.TagKeys = defaultTagKeys
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSeverity sets the severity of the changes of the given kind.
+//
+// For example, WithSeverity(ConstValueChanged, Breaking) reports a change
+// in the value of a constant as breaking rather than as a warning.
+func WithSeverity(kind ChangeKind, s Severity) Option {
+	return func(o *options) {
+		o.severity[kind] = s
+	}
+}
+
+// IncludeCompatible reports compatible changes, such as added names,
+// fields and methods, along with breaking changes and warnings.
+func IncludeCompatible() Option {
+	return func(o *options) {
+		o.compatible = true
+	}
+}
+
+// LenientNamedTypes compares named types by their structure rather than
+// by name: changing a parameter from time.Duration to int64, or from one
+// named struct type to another with the same fields, is then not reported.
+// By default, named types are identical only if they have the same name
+// and are declared in packages with the same import path.
+func LenientNamedTypes() Option {
+	return func(o *options) {
+		o.cmp.LenientNamed = true
+	}
+}
+
+// SourceCompatible only reports changes to functions and methods of
+// non-interface types that break calls to them. Adding a final variadic
+// parameter, changing a parameter of a non-basic type to an interface that
+// the type implements, changing an interface result to an interface that
+// has all of its methods, or widening the direction of a channel parameter
+// or result are then compatible, of kinds VariadicParamAdded,
+// ParamTypeWidened, ResultTypeNarrowed and ChanDirChanged. Such changes
+// still break code that uses the function as a value, or relies on a method
+// to implement an interface.
+func SourceCompatible() Option {
+	return func(o *options) {
+		o.cmp.SourceCompatible = true
+	}
+}
+
+// StructTags sets the keys of struct tags whose changes are reported,
+// with kind TagChanged and severity Serialization. By default, the keys
+// are those of common encodings: json, xml, yaml and protobuf.
+// StructTags() without keys ignores struct tags.
+func StructTags(keys ...string) Option {
+	return func(o *options) {
+		o.cmp.TagKeys = keys
+	}
+}
+
+// SeparateUnkeyedLiterals reports the changes to struct types that only
+// break unkeyed composite literals, such as T{1, 2}, as warnings of kind
+// UnkeyedLiteralBroken: adding a field to a struct with only exported
+// fields, or moving one. By default, adding an exported field is
+// compatible, and the other changes are breaking.
+func SeparateUnkeyedLiterals() Option {
+	return func(o *options) {
+		o.cmp.UnkeyedLiterals = true
+	}
+}
+
+// BuildTags sets additional build tags to satisfy when selecting the files
+// of packages, as with the -tags flag of the go command.
+func BuildTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = tags
+	}
+}
+
+// Platforms compares packages as built for each of the given platforms,
+// such as "linux/amd64" or "windows/arm64", rather than for the platform
+// of build.Default. A change found on every platform is reported once, as
+// usual; a change found on only some of them is reported once, with the
+// platforms returned by ObjectDiff.Platforms.
+//
+// The standard library is type-checked from source for platforms other
+// than that of build.Default.
+func Platforms(platforms ...string) Option {
+	return func(o *options) {
+		o.platforms = platforms
+	}
+}
+
+// AllowTypeErrors compares packages despite type errors, with the partial
+// type information found, rather than failing with a *CheckError. Changes
+// to the declarations involved in the errors may be missed or spurious.
+// If handle is not nil, it is called with the *CheckError of each version
+// of a package with type errors. Syntax errors still fail the comparison.
+func AllowTypeErrors(handle func(*CheckError)) Option {
+	return func(o *options) {
+		o.allowTypeErrors = true
+		o.onTypeError = handle
+	}
+}
+
+// typeErrors returns the type errors found in the old and new versions of
+// a package, erra and errb, unless allowed by AllowTypeErrors.
+func (o *options) typeErrors(erra, errb error) error {
+	var errs []error
+	for _, err := range []error{erra, errb} {
+		switch {
+		case err == nil:
+		case o.allowTypeErrors:
+			if o.onTypeError != nil {
+				o.onTypeError(err.(*CheckError))
+			}
+		default:
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+func (o *options) severityOf(c typecmp.Change) Severity {
+	if s, ok := o.severity[c.Kind]; ok {
+		return s
+	}
+	if c.Compatible {
+		return Compatible
+	}
+	if o.strict {
+		return Breaking
+	}
+	return defaultSeverity(c.Kind)
+}
+
+// appendDiffs appends to diffs the changes between x and y, in the package
+// with the given import path, that are reported under o.
+func (o *options) appendDiffs(diffs []*ObjectDiff, pkg string, x, y *Object, changes []typecmp.Change) []*ObjectDiff {
+	for _, c := range changes {
+		if o.disabled[c.Kind] {
+			continue
+		}
+		s := o.severityOf(c)
+		if s == Compatible && !o.compatible {
+			continue
+		}
+		diffs = append(diffs, &ObjectDiff{a: x, b: y, pkg: pkg, change: c, severity: s})
+	}
+	return diffs
+}
@@ -0,0 +1,80 @@
+package breaking
+
+import (
+	"fmt"
+	"go/build"
+	"strings"
+
+	"github.com/sprt/breaking/internal/typecmp"
+)
+
+// contexts returns the build contexts to select the files of packages
+// with: one per platform set with Platforms, or the base context, that of
+// Config.Context or build.Default. Cgo is disabled, as cgo files cannot be
+// type-checked.
+func (o *options) contexts() ([]*build.Context, error) {
+	base := build.Default
+	if o.ctxt != nil {
+		base = *o.ctxt
+	}
+	base.CgoEnabled = false
+	if o.tags != nil {
+		base.BuildTags = o.tags
+	}
+	if len(o.platforms) == 0 {
+		return []*build.Context{&base}, nil
+	}
+	ctxts := make([]*build.Context, 0, len(o.platforms))
+	for _, p := range o.platforms {
+		goos, goarch, ok := strings.Cut(p, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			return nil, fmt.Errorf("invalid platform %q: want GOOS/GOARCH", p)
+		}
+		ctxt := base
+		ctxt.GOOS, ctxt.GOARCH = goos, goarch
+		ctxt.ToolTags = nil // specific to the architecture of the host
+		ctxts = append(ctxts, &ctxt)
+	}
+	return ctxts, nil
+}
+
+// eachPlatform calls compare with the build context of each platform and
+// merges the changes found, as described for Platforms.
+func (o *options) eachPlatform(compare func(*build.Context) ([]*ObjectDiff, error)) ([]*ObjectDiff, error) {
+	ctxts, err := o.contexts()
+	if err != nil {
+		return nil, err
+	}
+	if len(ctxts) == 1 {
+		return compare(ctxts[0])
+	}
+
+	type key struct {
+		pkg, name string
+		change    typecmp.Change
+	}
+	var diffs []*ObjectDiff
+	found := make(map[key]*ObjectDiff)
+	for i, ctxt := range ctxts {
+		d, err := compare(ctxt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o.platforms[i], err)
+		}
+		for _, d := range d {
+			k := key{d.pkg, d.Name(), d.change}
+			if prev, ok := found[k]; ok {
+				prev.platforms = append(prev.platforms, o.platforms[i])
+				continue
+			}
+			d.platforms = []string{o.platforms[i]}
+			found[k] = d
+			diffs = append(diffs, d)
+		}
+	}
+	for _, d := range diffs {
+		if len(d.platforms) == len(ctxts) {
+			d.platforms = nil
+		}
+	}
+	return diffs, nil
+}
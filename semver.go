@@ -0,0 +1,178 @@
+package breaking
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Bump is the part of a semantic version to increment for a release.
+type Bump int
+
+const (
+	Patch Bump = iota // v1.2.3 to v1.2.4
+	Minor             // v1.2.3 to v1.3.0
+	Major             // v1.2.3 to v2.0.0
+)
+
+func (b Bump) String() string {
+	switch b {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	}
+	return "Bump(" + strconv.Itoa(int(b)) + ")"
+}
+
+// RecommendBump returns the part of the version to increment for a release
+// with the given changes: Major if any change is breaking, Minor if any is
+// compatible, such as an addition, and Patch otherwise.
+func RecommendBump(diffs []*ObjectDiff) Bump {
+	bump := Patch
+	for _, d := range diffs {
+		switch d.Severity() {
+		case Breaking:
+			return Major
+		case Compatible:
+			bump = Minor
+		}
+	}
+	return bump
+}
+
+// NextVersion returns the semantic version, such as v1.3.0, to release after
+// version current with the given changes, which should include compatible
+// changes (see IncludeCompatible).
+//
+// Before v1.0.0, anything may change: breaking changes increment the minor
+// version and other changes the patch version. From v2 on, the module path
+// must end in /vN, N being the major version; NextVersion returns an error
+// if current does not match modulePath, which may be empty if unknown.
+// A major version increment from v1 on also requires a new module path,
+// see VersionModulePath.
+func NextVersion(current, modulePath string, diffs []*ObjectDiff) (string, error) {
+	v, err := parseVersion(current)
+	if err != nil {
+		return "", err
+	}
+	if modulePath != "" {
+		if _, want := splitPathMajor(modulePath); want != v.major && !(want <= 1 && v.major <= 1) {
+			return "", fmt.Errorf("version %s does not match module path %s", current, modulePath)
+		}
+	}
+
+	bump := RecommendBump(diffs)
+	if v.major == 0 {
+		if bump == Major {
+			bump = Minor
+		} else {
+			bump = Patch
+		}
+	}
+	return v.next(bump).String(), nil
+}
+
+// VersionModulePath returns the path that the module at modulePath must have
+// at the given version: modulePath with its /vN suffix, if any, replaced by
+// that of the major version of version, or removed for v0 and v1. The .vN
+// suffix of a gopkg.in path is always kept, as in gopkg.in/yaml.v1.
+func VersionModulePath(modulePath, version string) (string, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	prefix, _ := splitPathMajor(modulePath)
+	switch {
+	case strings.HasPrefix(prefix, "gopkg.in/"):
+		return prefix + ".v" + strconv.Itoa(v.major), nil
+	case v.major > 1:
+		return prefix + "/v" + strconv.Itoa(v.major), nil
+	}
+	return prefix, nil
+}
+
+// A version is a semantic version.
+type version struct {
+	major, minor, patch int
+	pre                 string // pre-release, without the leading hyphen
+}
+
+// parseVersion parses a semantic version such as v1.2.3 or v1.2.3-rc.1.
+// Build metadata is ignored.
+func parseVersion(s string) (version, error) {
+	var v version
+	rest, ok := strings.CutPrefix(s, "v")
+	if !ok {
+		return v, fmt.Errorf("invalid semantic version %q: missing v prefix", s)
+	}
+	rest, _, _ = strings.Cut(rest, "+")
+	rest, v.pre, _ = strings.Cut(rest, "-")
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid semantic version %q", s)
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || strings.Trim(p, "0123456789") != "" || len(p) > 1 && p[0] == '0' {
+			return v, fmt.Errorf("invalid semantic version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.major, v.minor, v.patch)
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	return s
+}
+
+// next returns the version following v for the given bump.
+// The release of a pre-release version comes next if it is enough.
+func (v version) next(bump Bump) version {
+	pre := v.pre != ""
+	switch bump {
+	case Major:
+		if !pre || v.minor != 0 || v.patch != 0 {
+			v.major++
+		}
+		v.minor, v.patch = 0, 0
+	case Minor:
+		if !pre || v.patch != 0 {
+			v.minor++
+		}
+		v.patch = 0
+	case Patch:
+		if !pre {
+			v.patch++
+		}
+	}
+	v.pre = ""
+	return v
+}
+
+// splitPathMajor splits a module path into its prefix and the major version
+// N of its suffix: /vN, N > 1, or .vN for gopkg.in paths, as in
+// gopkg.in/yaml.v3. major is 1 if there is no such suffix.
+func splitPathMajor(modulePath string) (prefix string, major int) {
+	sep, min := "/v", 2
+	if strings.HasPrefix(modulePath, "gopkg.in/") {
+		sep, min = ".v", 0
+	}
+	i := strings.LastIndex(modulePath, sep)
+	if i < 0 {
+		return modulePath, 1
+	}
+	suffix := modulePath[i+2:]
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n < min || suffix != strconv.Itoa(n) {
+		return modulePath, 1
+	}
+	return modulePath[:i], n
+}
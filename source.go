@@ -0,0 +1,265 @@
+package breaking
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A Source provides the files of a version of a package, or of a tree
+// of packages, to compare. Files are only read as needed.
+//
+// The files of a package are those in the root directory of the source,
+// or in its only directory containing Go files.
+type Source interface {
+	// List returns the slash-separated paths of the files of the source,
+	// relative to its root. Directories ignored by the go command, such
+	// as testdata, may be omitted.
+	List() ([]string, error)
+
+	// Open opens the named file, a path returned by List.
+	Open(name string) (io.ReadCloser, error)
+
+	// ModulePath returns the path of the module at the root of the source,
+	// or the empty string if it is declared by the go.mod file at its root,
+	// if any.
+	ModulePath() string
+}
+
+// Dir is a Source reading files from a directory of the local file system.
+//
+// If the directory of a package is part of a module, imports are resolved
+// as described for ComparePackages.
+type Dir string
+
+// List lists the files below the directory, except in directories whose
+// names begin with . or _, or are named testdata.
+func (d Dir) List() ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(string(d), func(name string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(string(d), name)
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if rel != "." && ignoredDir(e.Name()) && e.Name() != "vendor" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	return names, err
+}
+
+func (d Dir) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d Dir) ModulePath() string {
+	return ""
+}
+
+// Files is a Source holding files in memory. It maps slash-separated paths,
+// relative to the root of a tree or to the directory of a package, to file
+// contents.
+type Files map[string][]byte
+
+func (f Files) List() ([]string, error) {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f Files) Open(name string) (io.ReadCloser, error) {
+	data, ok := f[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f Files) ModulePath() string {
+	return ""
+}
+
+// FS returns a Source reading files from fsys, such as an fstest.MapFS
+// or an embed.FS.
+func FS(fsys fs.FS) Source {
+	return &fsSource{fsys: fsys}
+}
+
+// Zip returns a Source reading the files of archive below the directory dir,
+// or at its root if dir is empty. If dir has the form path@version, as in
+// the module zip files served by module proxies, path is the module path
+// of the source.
+func Zip(archive *zip.Reader, dir string) (Source, error) {
+	src := &fsSource{fsys: archive}
+	if dir == "" {
+		return src, nil
+	}
+	sub, err := fs.Sub(archive, dir)
+	if err != nil {
+		return nil, err
+	}
+	src.fsys = sub
+	if modulePath, _, ok := strings.Cut(dir, "@"); ok {
+		src.modulePath = modulePath
+	}
+	return src, nil
+}
+
+// An fsSource is a Source reading files from a file system.
+type fsSource struct {
+	fsys       fs.FS
+	modulePath string
+}
+
+func (s *fsSource) List() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(s.fsys, ".", func(name string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if name != "." && ignoredDir(e.Name()) && e.Name() != "vendor" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+func (s *fsSource) Open(name string) (io.ReadCloser, error) {
+	return s.fsys.Open(name)
+}
+
+func (s *fsSource) ModulePath() string {
+	return s.modulePath
+}
+
+// GitTree returns a Source reading the files of treeish, such as a commit
+// or a tag, in the Git repository containing the directory dir. Only the
+// files below dir are included, relative to dir. Files are read with the
+// git command.
+func GitTree(dir, treeish string) Source {
+	return &gitTree{dir: dir, treeish: treeish}
+}
+
+// A gitTree is a Source reading files from a Git tree.
+type gitTree struct {
+	dir, treeish string
+}
+
+func (t *gitTree) List() ([]string, error) {
+	out, err := t.git("ls-tree", "-r", "-z", "--name-only", t.treeish)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"), nil
+}
+
+func (t *gitTree) Open(name string) (io.ReadCloser, error) {
+	out, err := t.git("show", t.treeish+":./"+name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(out)), nil
+}
+
+func (t *gitTree) ModulePath() string {
+	return ""
+}
+
+// git runs git in the directory of the tree and returns its output.
+func (t *gitTree) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = t.dir
+	out, err := cmd.Output()
+	if err, ok := err.(*exec.ExitError); ok && len(err.Stderr) > 0 {
+		return nil, fmt.Errorf("git %s: %s", args[0], bytes.TrimSpace(err.Stderr))
+	}
+	return out, err
+}
+
+// toSource returns the Source for f, a package or tree passed as to
+// ComparePackages or CompareTrees.
+func toSource(f interface{}) (Source, error) {
+	switch f := f.(type) {
+	case Source:
+		return f, nil
+	case string:
+		return Dir(f), nil
+	case map[string]io.Reader:
+		files := make(Files, len(f))
+		for name, r := range f {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			files[name] = data
+		}
+		return files, nil
+	}
+	return nil, fmt.Errorf("unsupported source of type %T", f)
+}
+
+// A sourceTree is the load.Tree of a Source, the files of which are listed
+// once.
+type sourceTree struct {
+	src   Source
+	names []string
+	dirs  map[string][]string // directory -> names of its files
+}
+
+func newSourceTree(src Source) (*sourceTree, error) {
+	names, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	t := &sourceTree{src: src, names: names, dirs: make(map[string][]string)}
+	for _, name := range names {
+		dir := path.Dir(name)
+		t.dirs[dir] = append(t.dirs[dir], path.Base(name))
+	}
+	return t, nil
+}
+
+func (t *sourceTree) ReadDir(dir string) ([]string, error) {
+	names, ok := t.dirs[path.Clean(dir)]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: fs.ErrNotExist}
+	}
+	return names, nil
+}
+
+func (t *sourceTree) ReadFile(name string) ([]byte, error) {
+	r, err := t.src.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
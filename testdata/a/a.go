@@ -32,6 +32,15 @@ type InterfaceMethRetTypeChanged interface {
 	Foo() int
 }
 
+type InterfaceMethodsReplaced interface {
+	Bar()
+	Foo(foo int)
+}
+
+// Methods cannot be added to interfaces that users may implement
+// to pass to the package.
+func AcceptInterfaces(InterfaceMethodAdded, InterfaceMethodsReplaced) {}
+
 type StructExportedAddedUnexported struct {
 	Foo int
 }
@@ -135,3 +144,315 @@ type StructUnexportedRepositioned struct {
 	foo int
 	bar string
 }
+
+// methods
+
+type MethodRemoved struct{}
+
+func (MethodRemoved) Close() error { return nil }
+
+type MethodSignatureChanged struct{}
+
+func (MethodSignatureChanged) Close() error { return nil }
+
+type MethodValueToPointer struct{}
+
+func (MethodValueToPointer) Close() error { return nil }
+
+type MethodPointerRemoved struct{}
+
+func (*MethodPointerRemoved) Close() error { return nil }
+
+type MethodPromotedRemoved struct {
+	MethodRemoved
+}
+
+type MethodAdded struct{}
+
+type MethodPointerToValue struct{}
+
+func (*MethodPointerToValue) Close() error { return nil }
+
+type MethodUnexportedRemoved struct{}
+
+func (MethodUnexportedRemoved) close() error { return nil }
+
+type MethodReceiverRenamed struct{}
+
+func (m MethodReceiverRenamed) Close() error { return nil }
+
+// constants
+
+const ConstValueChanged = 10
+
+const ConstUntypedToTyped = 10
+
+const ConstUnchanged = "foo"
+
+const ConstTypedUnchanged int = 1 << 10
+
+// generics
+
+func GenericTypeParamAdded[T any](t T) {}
+
+func GenericTypeParamRemoved[T, U any](t T) {}
+
+func GenericConstraintTightened[T any](t T) {}
+
+func GenericUnionTightened[T ~int | ~string](t T) {}
+
+func GenericMethodsTightened[T interface{ String() string }](t T) {}
+
+type GenericTypeTightened[T any] struct {
+	Foo T
+}
+
+type GenericInstantiationChanged struct {
+	Foo GenericBox[int]
+}
+
+func GenericConstraintLoosened[T comparable](t T) {}
+
+func GenericUnionLoosened[T int](t T) {}
+
+func GenericMethodsLoosened[T interface {
+	String() string
+	Len() int
+}](t T) {
+}
+
+func GenericTypeParamRenamed[T any](t T) T { return t }
+
+type GenericBox[T any] struct {
+	Value T
+}
+
+func (b GenericBox[T]) Get() T { return b.Value }
+
+type GenericTypeLoosened[T ~int] struct {
+	Foo T
+}
+
+type GenericInstantiationUnchanged struct {
+	Foo GenericBox[int]
+}
+
+// named types
+
+type NamedStructA struct {
+	Foo int
+}
+
+type NamedStructB struct {
+	Foo int
+}
+
+func FuncParamNamedToUnderlying(n NamedType) {}
+
+func FuncParamNamedSwapped(s NamedStructA) {}
+
+func FuncParamNamedUnchanged(s NamedStructA) {}
+
+// interfaces
+
+type InterfaceSealedMethodAdded interface {
+	Foo()
+	sealed()
+}
+
+type InterfaceReturnedMethodAdded interface {
+	Foo()
+}
+
+func NewInterfaceReturned() InterfaceReturnedMethodAdded { return nil }
+
+type InterfaceUnusedMethodAdded interface {
+	Foo()
+}
+
+type InterfaceConstraintMethodAdded interface {
+	Foo()
+}
+
+func NewInterfaceConstraint() InterfaceConstraintMethodAdded { return nil }
+
+func GenericInterfaceConstraint[T InterfaceConstraintMethodAdded](x T) {}
+
+// embedding
+
+type EmbeddedInner struct {
+	Foo int
+}
+
+func (EmbeddedInner) Close() error { return nil }
+
+type EmbeddedOther struct {
+	Foo int
+}
+
+func (EmbeddedOther) Close() error { return nil }
+
+type embeddedInner struct {
+	Foo int
+}
+
+func (embeddedInner) Close() error { return nil }
+
+type EmbeddedPromotedRemoved struct {
+	embeddedInner
+}
+
+type EmbeddedAmbiguous struct {
+	EmbeddedInner
+}
+
+type EmbeddedPromotedAdded struct {
+	Bar int
+}
+
+// struct tags
+
+type StructTagsChanged struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name" db:"name"`
+	Age     int
+	Kept    int `json:"kept,omitempty" xml:"kept"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+// comparability
+
+type ComparabilityLostStruct struct {
+	Foo int
+}
+
+type ComparabilityLostArray [2]ComparabilityLostStruct
+
+type ComparabilityKept struct {
+	Foo int
+}
+
+// channels
+
+func ChanParamWidened(c chan int) {}
+
+func ChanParamNarrowed(c <-chan int) {}
+
+func ChanResultNarrowed() chan int { return nil }
+
+func ChanResultWidened() <-chan int { return nil }
+
+type ChanInterfaceParamWidened interface {
+	Foo(c chan int)
+}
+
+// object kinds
+
+const KindConstToVar int = 1
+
+const KindConstToFunc = 1
+
+var KindVarToConst = 1
+
+var KindFuncVarToFunc = func(int) {}
+
+func KindFuncToFuncVar(int) {}
+
+func KindFuncToFuncVarTypeChanged(int) {}
+
+// unexported types
+
+type unexportedReachable struct {
+	Foo int
+	Bar int
+}
+
+func (unexportedReachable) Close() error { return nil }
+
+func UnexportedReachable() unexportedReachable { return unexportedReachable{} }
+
+type unexportedUnreachable struct {
+	Foo int
+}
+
+type unexportedLiteral struct {
+	Foo int
+	Bar int
+}
+
+func UnexportedLiteral() unexportedLiteral { return unexportedLiteral{} }
+
+// named constraints
+
+type ConstraintNamedTightened interface {
+	~int | ~float64
+}
+
+func GenericNamedTightened[T ConstraintNamedTightened](x T) {}
+
+type ConstraintNamedLoosened interface {
+	~int
+}
+
+func GenericNamedLoosened[T ConstraintNamedLoosened](x T) {}
+
+// source compatibility
+
+type Stringer interface {
+	String() string
+}
+
+type StringerImpl struct{}
+
+func (StringerImpl) String() string { return "" }
+
+type RichStringer interface {
+	String() string
+	Len() int
+}
+
+type StringerInt int
+
+func (StringerInt) String() string { return "" }
+
+func SourceVariadicAdded(a int) {}
+
+func SourceParamWidened(s StringerImpl) {}
+
+func SourceResultNarrowed() Stringer { return nil }
+
+func SourceResultConcrete() Stringer { return nil }
+
+func SourceParamBasicWidened(d StringerInt) {}
+
+func SourceParamNarrowed(s Stringer) {}
+
+type SourceInterfaceResultNarrowed interface {
+	Foo() Stringer
+}
+
+// aliases
+
+type AliasTarget struct {
+	Foo int
+}
+
+type AliasUnchanged = AliasTarget
+
+type AliasTypeChanged = int
+
+type AliasStructFieldAdded = struct{ Foo int }
+
+type AliasGenericUnchanged[T any] = GenericBox[T]
+
+type DefinedToAlias struct {
+	Foo int
+}
+
+func FuncParamDefinedToAlias(d DefinedToAlias) {}
+
+type DefinedToAliasLiteral []int
+
+type AliasToDefined = int
@@ -0,0 +1,3 @@
+package main
+
+func PlatformSplit(i int) {}
@@ -0,0 +1,5 @@
+//go:build breakingtag
+
+package main
+
+func BuildTagged() {}
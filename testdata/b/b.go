@@ -34,6 +34,15 @@ type InterfaceMethRetTypeChanged interface {
 	Foo() float64
 }
 
+type InterfaceMethodsReplaced interface {
+	Baz()
+	Foo(foo string)
+}
+
+// Methods cannot be added to interfaces that users may implement
+// to pass to the package.
+func AcceptInterfaces(InterfaceMethodAdded, InterfaceMethodsReplaced) {}
+
 type StructExportedAddedUnexported struct {
 	Foo, foo int
 }
@@ -130,3 +139,318 @@ type StructMixedRepositionedUnexported struct {
 
 type StructUnexportedRemoved struct {
 }
+
+// methods
+
+type MethodRemoved struct{}
+
+type MethodSignatureChanged struct{}
+
+func (MethodSignatureChanged) Close() {}
+
+type MethodValueToPointer struct{}
+
+func (*MethodValueToPointer) Close() error { return nil }
+
+type MethodPointerRemoved struct{}
+
+type MethodPromotedRemoved struct {
+	MethodRemoved
+}
+
+type MethodAdded struct{}
+
+func (MethodAdded) Close() error { return nil }
+
+type MethodPointerToValue struct{}
+
+func (MethodPointerToValue) Close() error { return nil }
+
+type MethodUnexportedRemoved struct{}
+
+type MethodReceiverRenamed struct{}
+
+func (r MethodReceiverRenamed) Close() error { return nil }
+
+// constants
+
+const ConstValueChanged = 20
+
+const ConstUntypedToTyped int = 10
+
+const ConstUnchanged = "foo"
+
+const ConstTypedUnchanged int = 1024
+
+// generics
+
+func GenericTypeParamAdded[T, U any](t T) {}
+
+func GenericTypeParamRemoved[T any](t T) {}
+
+func GenericConstraintTightened[T comparable](t T) {}
+
+func GenericUnionTightened[T ~int](t T) {}
+
+func GenericMethodsTightened[T interface {
+	String() string
+	Len() int
+}](t T) {
+}
+
+type GenericTypeTightened[T interface{ String() string }] struct {
+	Foo T
+}
+
+type GenericInstantiationChanged struct {
+	Foo GenericBox[string]
+}
+
+func GenericConstraintLoosened[T any](t T) {}
+
+func GenericUnionLoosened[T ~int | ~string](t T) {}
+
+func GenericMethodsLoosened[T interface{ String() string }](t T) {}
+
+func GenericTypeParamRenamed[U any](u U) U { return u }
+
+type GenericBox[T any] struct {
+	Value T
+}
+
+func (b GenericBox[T]) Get() T { return b.Value }
+
+type GenericTypeLoosened[T ~int | ~float64] struct {
+	Foo T
+}
+
+type GenericInstantiationUnchanged struct {
+	Foo GenericBox[int]
+}
+
+// named types
+
+type NamedStructA struct {
+	Foo int
+}
+
+type NamedStructB struct {
+	Foo int
+}
+
+func FuncParamNamedToUnderlying(n int) {}
+
+func FuncParamNamedSwapped(s NamedStructB) {}
+
+func FuncParamNamedUnchanged(s NamedStructA) {}
+
+// interfaces
+
+type InterfaceSealedMethodAdded interface {
+	Foo()
+	Bar()
+	sealed()
+}
+
+type InterfaceReturnedMethodAdded interface {
+	Foo()
+	Bar()
+}
+
+func NewInterfaceReturned() InterfaceReturnedMethodAdded { return nil }
+
+type InterfaceUnusedMethodAdded interface {
+	Foo()
+	Bar()
+}
+
+type InterfaceConstraintMethodAdded interface {
+	Foo()
+	Bar()
+}
+
+func NewInterfaceConstraint() InterfaceConstraintMethodAdded { return nil }
+
+func GenericInterfaceConstraint[T InterfaceConstraintMethodAdded](x T) {}
+
+// embedding
+
+type EmbeddedInner struct {
+	Foo int
+}
+
+func (EmbeddedInner) Close() error { return nil }
+
+type EmbeddedOther struct {
+	Foo int
+}
+
+func (EmbeddedOther) Close() error { return nil }
+
+type embeddedInner struct {
+	Foo int
+}
+
+func (embeddedInner) Close() error { return nil }
+
+type EmbeddedPromotedRemoved struct{}
+
+type EmbeddedAmbiguous struct {
+	EmbeddedInner
+	EmbeddedOther
+}
+
+type EmbeddedPromotedAdded struct {
+	Bar int
+	EmbeddedInner
+}
+
+// struct tags
+
+type StructTagsChanged struct {
+	ID      int    `json:"user_id"`
+	Name    string `json:"name" db:"full_name"`
+	Age     int    `json:"age"`
+	Kept    int    `json:"kept,omitempty" xml:"kept"`
+	Address struct {
+		City string `json:"town"`
+	} `json:"address"`
+}
+
+// comparability
+
+type ComparabilityLostStruct struct {
+	Foo int
+	Bar []int
+}
+
+type ComparabilityLostArray [2]ComparabilityLostStruct
+
+type ComparabilityKept struct {
+	Foo int
+	Bar string
+}
+
+// channels
+
+func ChanParamWidened(c <-chan int) {}
+
+func ChanParamNarrowed(c chan int) {}
+
+func ChanResultNarrowed() <-chan int { return nil }
+
+func ChanResultWidened() chan int { return nil }
+
+type ChanInterfaceParamWidened interface {
+	Foo(c <-chan int)
+}
+
+// object kinds
+
+var KindConstToVar int = 1
+
+func KindConstToFunc() {}
+
+const KindVarToConst = 1
+
+func KindFuncVarToFunc(int) {}
+
+var KindFuncToFuncVar = func(int) {}
+
+var KindFuncToFuncVarTypeChanged = func(string) {}
+
+// unexported types
+
+type unexportedReachable struct {
+	Bar string
+}
+
+func UnexportedReachable() unexportedReachable { return unexportedReachable{} }
+
+type unexportedUnreachable struct{}
+
+type unexportedLiteral struct {
+	Bar int
+	Foo int
+	baz int
+}
+
+func UnexportedLiteral() unexportedLiteral { return unexportedLiteral{} }
+
+// named constraints
+
+type ConstraintNamedTightened interface {
+	~int
+}
+
+func GenericNamedTightened[T ConstraintNamedTightened](x T) {}
+
+type ConstraintNamedLoosened interface {
+	~int | ~float64
+}
+
+func GenericNamedLoosened[T ConstraintNamedLoosened](x T) {}
+
+// source compatibility
+
+type Stringer interface {
+	String() string
+}
+
+type StringerImpl struct{}
+
+func (StringerImpl) String() string { return "" }
+
+type RichStringer interface {
+	String() string
+	Len() int
+}
+
+type StringerInt int
+
+func (StringerInt) String() string { return "" }
+
+func SourceVariadicAdded(a int, opts ...string) {}
+
+func SourceParamWidened(s Stringer) {}
+
+func SourceResultNarrowed() RichStringer { return nil }
+
+func SourceResultConcrete() StringerImpl { return StringerImpl{} }
+
+func SourceParamBasicWidened(s Stringer) {}
+
+func SourceParamNarrowed(s StringerImpl) {}
+
+type SourceInterfaceResultNarrowed interface {
+	Foo() StringerImpl
+}
+
+// aliases
+
+type AliasTarget struct {
+	Foo int
+}
+
+type AliasUnchanged = AliasTarget
+
+type AliasTypeChanged = string
+
+type AliasStructFieldAdded = struct {
+	Foo int
+	Bar int
+}
+
+type AliasGenericUnchanged[T any] = GenericBox[T]
+
+type DefinedToAlias = AliasTarget
+
+func FuncParamDefinedToAlias(d DefinedToAlias) {}
+
+type DefinedToAliasLiteral = []int
+
+type AliasToDefined int
+
+// added
+
+func FuncAdded() {}
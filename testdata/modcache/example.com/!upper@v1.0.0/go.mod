@@ -0,0 +1 @@
+module example.com/Upper
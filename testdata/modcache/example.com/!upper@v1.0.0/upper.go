@@ -0,0 +1,3 @@
+package upper
+
+type T struct{}
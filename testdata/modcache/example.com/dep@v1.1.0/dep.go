@@ -0,0 +1,3 @@
+package dep
+
+type Value string
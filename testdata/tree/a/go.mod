@@ -0,0 +1,8 @@
+module example.com/tree
+
+go 1.21
+
+require (
+	example.com/Upper v1.0.0
+	example.com/dep v1.0.0
+)
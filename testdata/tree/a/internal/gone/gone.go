@@ -0,0 +1,3 @@
+package gone
+
+func Removed() {}
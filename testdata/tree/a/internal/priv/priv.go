@@ -0,0 +1,3 @@
+package priv
+
+func Changed(x int) {}
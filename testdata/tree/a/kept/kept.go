@@ -0,0 +1,17 @@
+package kept
+
+import (
+	"example.com/Upper"
+	"example.com/dep"
+	"example.com/tree"
+)
+
+func Kept() {}
+
+func Changed(i int) {}
+
+func UsesRoot(t tree.T) {}
+
+func UsesDep() dep.Value { return 0 }
+
+func UsesUpper() upper.T { return upper.T{} }
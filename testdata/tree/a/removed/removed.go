@@ -0,0 +1,3 @@
+package removed
+
+func Removed() {}
@@ -0,0 +1,3 @@
+package added
+
+func Added() {}
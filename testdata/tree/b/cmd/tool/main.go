@@ -0,0 +1,5 @@
+package main
+
+func Changed(x string) {}
+
+func main() {}
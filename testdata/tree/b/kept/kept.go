@@ -0,0 +1,17 @@
+package kept
+
+import (
+	"example.com/Upper"
+	"example.com/dep"
+	"example.com/tree"
+)
+
+func Kept() {}
+
+func Changed(s string) {}
+
+func UsesRoot(t tree.T) {}
+
+func UsesDep() dep.Value { return "" }
+
+func UsesUpper() upper.T { return upper.T{} }
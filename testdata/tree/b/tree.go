@@ -0,0 +1,5 @@
+package tree
+
+type T int
+
+func Root() {}
@@ -0,0 +1,5 @@
+package typeerrors
+
+func Changed(i int) {}
+
+func Unchanged() {}
@@ -0,0 +1,7 @@
+package typeerrors
+
+func Changed(s string) {}
+
+func Unchanged() {}
+
+func Broken() Undefined { return nil }
@@ -0,0 +1,361 @@
+package breaking
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sprt/breaking/internal/load"
+	"github.com/sprt/breaking/internal/typecmp"
+)
+
+// CompareTrees returns the changes introduced by tree b relative to tree a,
+// comparing every package they contain, as with the ./... pattern of the go
+// command. Removing a package is a breaking change; adding one is a
+// compatible change.
+//
+// A tree can be passed as either a string, a map of string -> io.Reader or
+// a Source. If a string, it is the path to the root directory of the tree,
+// as with Dir. If a map, it maps slash-separated paths relative to the root
+// of the tree to file contents, as with Files. CompareTrees returns an error
+// for any other type.
+//
+// Packages are identified by import path, made of the module path of the
+// Source or declared in the go.mod file at the root of the tree, if any,
+// and of the directory of the package. If there is a go.mod file, imports
+// are resolved as described for ComparePackages. Directories named testdata
+// or vendor, or beginning with . or _, are ignored, as are nested modules.
+//
+// Packages that other modules cannot import are not compared: internal
+// packages, below a directory named internal, and commands, in package
+// main.
+//
+// Files are selected by their build constraints as described for
+// ComparePackages. A package none of whose files are selected for a
+// platform is missing on that platform.
+//
+// See Config.CompareTrees for a typed alternative.
+func CompareTrees(a, b interface{}, opts ...Option) ([]*ObjectDiff, error) {
+	sa, err := toSource(a)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := toSource(b)
+	if err != nil {
+		return nil, err
+	}
+	return compareSourceTrees(sa, sb, newOptions(opts))
+}
+
+// compareSourceTrees is like CompareTrees for trees a and b.
+func compareSourceTrees(a, b Source, o *options) ([]*ObjectDiff, error) {
+	treea, err := loadTree(a)
+	if err != nil {
+		return nil, err
+	}
+
+	treeb, err := loadTree(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.eachPlatform(func(ctxt *build.Context) ([]*ObjectDiff, error) {
+		impa, impb := o.importer, o.importer
+		if impa == nil {
+			impa, err = treea.importer(ctxt)
+			if err != nil {
+				return nil, err
+			}
+			impb, err = treeb.importer(ctxt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pkgsa, pkgsb := treea.importPaths(ctxt), treeb.importPaths(ctxt)
+
+		var diffs []*ObjectDiff
+		for _, importPath := range pkgsa {
+			if !contains(pkgsb, importPath) {
+				change := typecmp.Change{Kind: PackageRemoved, Msg: "package removed"}
+				diffs = o.appendDiffs(diffs, importPath, &Object{}, &Object{}, []typecmp.Change{change})
+				continue
+			}
+			d, err := comparePackages(treea.pkg(importPath), treeb.pkg(importPath), importPath, impa, impb, ctxt, o)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, d...)
+		}
+
+		for _, importPath := range pkgsb {
+			if !contains(pkgsa, importPath) {
+				change := typecmp.Change{Kind: PackageAdded, Compatible: true, Msg: "package added"}
+				diffs = o.appendDiffs(diffs, importPath, &Object{}, &Object{}, []typecmp.Change{change})
+			}
+		}
+		return diffs, nil
+	})
+}
+
+// A tree is a source tree, the packages of which are identified by
+// import path.
+type tree struct {
+	src  load.Tree
+	root string            // root directory of src, if on disk
+	mod  bool              // whether there is a go.mod file at the root
+	pkgs map[string]string // import path -> slash-separated directory
+}
+
+// importer returns an importer for the packages of t selecting files with
+// ctxt, or nil if t is not a module.
+func (t *tree) importer(ctxt *build.Context) (types.Importer, error) {
+	if !t.mod {
+		return nil, nil
+	}
+	return load.NewImporter(t.src, ctxt)
+}
+
+// importPaths returns the sorted import paths of the packages of t that
+// have files selected by ctxt and that other modules can import: internal
+// packages and commands are left out.
+func (t *tree) importPaths(ctxt *build.Context) []string {
+	ctxt = load.BuildContext(ctxt, t.src)
+	paths := make([]string, 0, len(t.pkgs))
+	for p, dir := range t.pkgs {
+		if isInternal(dir) {
+			continue
+		}
+		names, _ := t.src.ReadDir(dir)
+		for _, name := range names {
+			if ok, err := ctxt.MatchFile(dir, name); isPackageFile(name) && err == nil && ok {
+				if !t.isCommand(path.Join(dir, name)) {
+					paths = append(paths, p)
+				}
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// isCommand reports whether the named file belongs to package main.
+func (t *tree) isCommand(name string) bool {
+	data, err := t.src.ReadFile(name)
+	if err != nil {
+		return false
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), name, data, parser.PackageClauseOnly)
+	return err == nil && f.Name.Name == "main"
+}
+
+// isInternal reports whether the slash-separated directory dir, relative
+// to the root of a tree, has an element named internal, so that only the
+// packages of the tree can import it.
+func isInternal(dir string) bool {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// pkg returns the package with the given import path, passed as to
+// ComparePackages.
+func (t *tree) pkg(importPath string) interface{} {
+	dir := t.pkgs[importPath]
+	if t.root != "" {
+		return filepath.Join(t.root, filepath.FromSlash(dir))
+	}
+	return treeDir{t.src, dir}
+}
+
+// loadTree loads the tree of src. The module path is that of src, if any,
+// or else that declared by its go.mod file.
+func loadTree(src Source) (*tree, error) {
+	var tt *tree
+	var err error
+	if d, ok := src.(Dir); ok {
+		tt, err = loadTreeDir(string(d))
+	} else {
+		var st *sourceTree
+		st, err = newSourceTree(src)
+		if err == nil {
+			tt = loadTreeFiles(st)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath := src.ModulePath()
+	if data, err := tt.src.ReadFile("go.mod"); err == nil {
+		mf, err := load.ParseModFile(data)
+		if err != nil {
+			return nil, err
+		}
+		tt.mod = true
+		if modulePath == "" {
+			modulePath = mf.Module
+		}
+	}
+
+	pkgs := make(map[string]string, len(tt.pkgs))
+	for _, dir := range tt.pkgs {
+		pkgs[joinImportPath(modulePath, dir)] = dir
+	}
+	tt.pkgs = pkgs
+	return tt, nil
+}
+
+// loadTreeDir loads the tree rooted at a directory. Packages are keyed by
+// directory until loadTree resolves their import paths.
+func loadTreeDir(root string) (*tree, error) {
+	// filepath.Walk does not follow a symbolic link to the root.
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	t := &tree{
+		src:  load.Dir(root),
+		root: root,
+		pkgs: make(map[string]string),
+	}
+	err = filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			if isPackageFile(info.Name()) {
+				rel, err := filepath.Rel(root, filepath.Dir(name))
+				if err != nil {
+					return err
+				}
+				t.pkgs[filepath.ToSlash(rel)] = filepath.ToSlash(rel)
+			}
+			return nil
+		}
+		if name == root {
+			return nil
+		}
+		if ignoredDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(name, "go.mod")); err == nil {
+			return filepath.SkipDir // nested module
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// loadTreeFiles is like loadTreeDir for a tree read from a Source.
+func loadTreeFiles(src *sourceTree) *tree {
+	nested := make(map[string]bool)
+	for _, name := range src.names {
+		if dir := path.Dir(name); path.Base(name) == "go.mod" && dir != "." {
+			nested[dir] = true
+		}
+	}
+
+	t := &tree{
+		src:  src,
+		pkgs: make(map[string]string),
+	}
+	for _, name := range src.names {
+		if dir := path.Dir(name); isPackageFile(path.Base(name)) && !ignoredPath(dir, nested) {
+			t.pkgs[dir] = dir
+		}
+	}
+	return t
+}
+
+// A treeDir is the package in a directory of a tree not on disk.
+type treeDir struct {
+	t   load.Tree
+	dir string // slash-separated
+}
+
+// loadPackage returns the package of src, passed as to parseAndCheckPackage:
+// either a directory or a treeDir.
+func loadPackage(src Source) (interface{}, error) {
+	if d, ok := src.(Dir); ok {
+		return string(d), nil
+	}
+	t, err := newSourceTree(src)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for dir, names := range t.dirs {
+		for _, name := range names {
+			if isPackageFile(name) && !ignoredPath(dir, nil) {
+				dirs = append(dirs, dir)
+				break
+			}
+		}
+	}
+	switch {
+	case contains(dirs, "."):
+		return treeDir{t, "."}, nil
+	case len(dirs) == 1:
+		return treeDir{t, dirs[0]}, nil
+	case len(dirs) == 0:
+		return nil, errors.New("no package found")
+	}
+	sort.Strings(dirs)
+	return nil, fmt.Errorf("several packages found: %s", strings.Join(dirs, ", "))
+}
+
+// isPackageFile reports whether the file with the given base name
+// is part of the API of a package.
+func isPackageFile(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+func ignoredDir(name string) bool {
+	return name == "testdata" || name == "vendor" ||
+		strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// ignoredPath reports whether the slash-separated directory dir, relative
+// to the root of a tree, is ignored or part of a nested module.
+func ignoredPath(dir string, nested map[string]bool) bool {
+	for d := dir; d != "."; d = path.Dir(d) {
+		if ignoredDir(path.Base(d)) || nested[d] {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func joinImportPath(modulePath, dir string) string {
+	if modulePath == "" {
+		return dir
+	}
+	if dir == "." {
+		return modulePath
+	}
+	return modulePath + "/" + dir
+}